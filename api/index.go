@@ -13,8 +13,8 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 		if err := recover(); err != nil {
 			// Ensure CORS headers are set even in case of panic
 			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Correlation-ID")
+			w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, PATCH, DELETE")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Correlation-ID, Idempotency-Key")
 
 			http.Error(w, "Internal Server Error: Panic detected", http.StatusInternalServerError)
 			// Log the panic for Vercel logs
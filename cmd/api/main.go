@@ -1,38 +1,76 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/femisowemimo/booking-appointment/backend/pkg/bootstrap"
-	"github.com/joho/godotenv"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/config"
 )
 
 func main() {
-	// Load .env file if it exists
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found or error loading it (using system env)")
+	storage := flag.String("storage", "", `"postgres" or "memory" (overrides STORAGE); memory needs no database`)
+	flag.Parse()
+
+	// Reads .env, the optional YAML file and the environment
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if *storage != "" {
+		cfg.Storage = *storage
+		if err := cfg.Validate(); err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
 	}
 
 	log.Println("Starting Reservation API Service...")
 
-	h := bootstrap.GetHandler()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	h := bootstrap.NewHandler(cfg)
 
 	// 7. Start Server
-	port := os.Getenv("PORT")
-	addr := ""
-
-	if port == "" {
-		// Local Dev: Listen on localhost only to avoid firewall popup
-		addr = "127.0.0.1:8080"
-	} else {
-		// Prod: Listen on all interfaces
-		addr = ":" + port
+	srv := &http.Server{
+		Addr:              cfg.Addr(),
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       120 * time.Second,
 	}
 
-	log.Printf("Server listening on %s", addr)
-	if err := http.ListenAndServe(addr, h); err != nil {
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server listening on %s", srv.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
 		log.Fatalf("Failed to start server: %v", err)
+	case <-ctx.Done():
+	}
+
+	// 8. Graceful Shutdown
+	// Stop accepting connections and let in-flight requests finish
+	log.Printf("Shutting down (timeout %s)...", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Server did not drain in time: %v", err)
+	}
+	if err := bootstrap.Close(); err != nil {
+		log.Printf("Failed to close database: %v", err)
 	}
+	log.Println("Server stopped")
 }
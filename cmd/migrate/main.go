@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/femisowemimo/booking-appointment/backend/migrations"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/config"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/migrate"
+	_ "github.com/lib/pq"
+)
+
+const usage = "Usage: migrate up | down [-steps N] | status | create [-dir DIR] NAME"
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+	command, args := os.Args[1], os.Args[2:]
+
+	// create only touches the filesystem
+	if command == "create" {
+		fs := flag.NewFlagSet("create", flag.ExitOnError)
+		dir := fs.String("dir", "migrations", "directory holding the migration files")
+		fs.Parse(args)
+		if fs.NArg() != 1 {
+			log.Fatal(usage)
+		}
+
+		up, down, err := migrate.Create(*dir, fs.Arg(0))
+		if err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		log.Printf("Created %s and %s", up, down)
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to open DB driver: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	ctx := context.Background()
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			log.Printf("Applied %03d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		if len(applied) == 0 {
+			log.Println("Database is up to date")
+		}
+	case "down":
+		fs := flag.NewFlagSet("down", flag.ExitOnError)
+		steps := fs.Int("steps", 1, "number of migrations to roll back")
+		fs.Parse(args)
+
+		reverted, err := migrator.Down(ctx, *steps)
+		for _, m := range reverted {
+			log.Printf("Reverted %03d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Rollback failed: %v", err)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(statuses)
+	default:
+		log.Fatal(usage)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/messaging"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/config"
+)
+
+// runDLQ implements `worker dlq inspect|replay [-limit N]`.
+func runDLQ(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		log.Fatal("Usage: worker dlq inspect|replay [-limit N]")
+	}
+
+	fs := flag.NewFlagSet("dlq "+args[0], flag.ExitOnError)
+	limit := fs.Int("limit", 100, "maximum number of messages to process")
+	fs.Parse(args[1:])
+
+	rabbitConn := dialRabbitMQ(cfg)
+	defer rabbitConn.Close()
+
+	dlq := messaging.NewDeadLetterQueue(rabbitConn)
+
+	switch args[0] {
+	case "inspect":
+		letters, err := dlq.Inspect(context.Background(), *limit)
+		if err != nil {
+			log.Fatalf("Failed to inspect dead-letter queue: %v", err)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(letters)
+	case "replay":
+		n, err := dlq.Replay(context.Background(), *limit)
+		if err != nil {
+			log.Fatalf("Replayed %d messages before failing: %v", n, err)
+		}
+		log.Printf("Replayed %d messages onto %s", n, messaging.QueueName)
+	default:
+		log.Fatalf("Unknown dlq command %q (available: inspect, replay)", args[0])
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/messaging"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/repositories"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/bootstrap"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/config"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+)
+
+// rebuildChunk is how many reservations are written between rate checks.
+const rebuildChunk = 25
+
+// runRebuild implements `worker rebuild [-table NAME] [-create] [-page-size N] [-rate N] [-after ID]`.
+//
+// It regenerates the read model from Postgres. Writing into the live table
+// fixes every item but cannot remove orphans (e.g. left behind by a key
+// change); to get a clean projection, rebuild into a new table with -create
+// and then point DYNAMODB_TABLE at it.
+//
+// A table the worker is not writing to (another name, or one just created)
+// is filled with throttled BatchWriteItem requests. The live table gets
+// one conditional write per reservation instead, which only lands if the
+// version last applied for the reservation is older, so a page read just
+// before a change cannot undo what the worker projects for it.
+func runRebuild(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("rebuild", flag.ExitOnError)
+	table := fs.String("table", cfg.DynamoDBTable, "DynamoDB table to write into")
+	create := fs.Bool("create", false, "create the table first if it does not exist")
+	pageSize := fs.Int("page-size", 500, "reservations read from Postgres per page")
+	rate := fs.Int("rate", 200, "maximum items written per second (0 for unlimited)")
+	after := fs.String("after", "", "resume after this reservation id")
+	fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to open DB driver: %v", err)
+	}
+	defer db.Close()
+
+	dynamoClient, err := bootstrap.NewDynamoDBClient(ctx, cfg.AWS)
+	if err != nil {
+		log.Fatalf("unable to load SDK config, %v", err)
+	}
+
+	created := false
+	if *create {
+		created = ensureTableExists(ctx, dynamoClient, *table)
+		waiter := dynamodb.NewTableExistsWaiter(dynamoClient)
+		if err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(*table)}, 2*time.Minute); err != nil {
+			log.Fatalf("Table %s did not become active: %v", *table, err)
+		}
+	}
+
+	source := repositories.NewPostgresReservationRepository(db)
+	target := repositories.NewDynamoDBReservationRepository(dynamoClient, *table)
+	write, mode := target.SaveReadModels, "conditional writes"
+	if created || *table != cfg.DynamoDBTable {
+		write, mode = target.BatchSaveReadModel, "batch writes"
+	}
+
+	log.Printf("Rebuilding read model into %s with %s...", *table, mode)
+	began := time.Now()
+	written := 0
+	afterID := *after
+	for {
+		page, err := source.ListAfter(ctx, afterID, *pageSize)
+		if err != nil {
+			log.Fatalf("Failed to read reservations after %q: %v", afterID, err)
+		}
+		if len(page) == 0 {
+			break
+		}
+
+		// Same projection as live events, so the items are indistinguishable
+		items := make([]*domain.Reservation, 0, len(page))
+		for _, res := range page {
+			items = append(items, messaging.ProjectSnapshot(domain.NewReservationSnapshot(res), res.Version, time.Now()))
+		}
+
+		// Write a chunk at a time, pacing the chunks to the requested rate
+		// so live traffic keeps its capacity
+		for i := 0; i < len(items); i += rebuildChunk {
+			chunk := items[i:min(i+rebuildChunk, len(items))]
+			chunkStart := time.Now()
+			if err := write(ctx, chunk); err != nil {
+				log.Fatalf("Failed to write reservations after %q (%d written so far): %v", afterID, written, err)
+			}
+			written += len(chunk)
+			afterID = chunk[len(chunk)-1].ID
+
+			if *rate > 0 {
+				budget := time.Duration(len(chunk)) * time.Second / time.Duration(*rate)
+				if wait := budget - time.Since(chunkStart); wait > 0 {
+					select {
+					case <-ctx.Done():
+					case <-time.After(wait):
+					}
+				}
+			}
+			if ctx.Err() != nil {
+				log.Fatalf("Interrupted after %d reservations; resume with -after %s", written, afterID)
+			}
+		}
+		log.Printf("Wrote %d reservations (last id %s)", written, afterID)
+	}
+
+	log.Printf("Rebuilt %d reservations into %s in %s", written, *table, time.Since(began).Round(time.Millisecond))
+	if *table != cfg.DynamoDBTable {
+		log.Printf("Set DYNAMODB_TABLE=%s and restart the API and worker to switch to the new table", *table)
+	}
+}
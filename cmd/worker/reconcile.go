@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/messaging"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/repositories"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/bootstrap"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/config"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+)
+
+// ReconcileReport lists the differences found for one event. Missing
+// items exist in Postgres only, extra items in the read model only, and
+// mismatched items exist in both with different content.
+type ReconcileReport struct {
+	EventID    string              `json:"event_id"`
+	Start      time.Time           `json:"start"`
+	End        time.Time           `json:"end"`
+	Checked    int                 `json:"checked"`
+	Missing    []ReconcileItem     `json:"missing"`
+	Extra      []ReconcileItem     `json:"extra"`
+	Mismatched []ReconcileMismatch `json:"mismatched"`
+	Repaired   int                 `json:"repaired"`
+	Skipped    int                 `json:"skipped"`
+
+	// Items a repair has to write (projected from Postgres) or delete
+	toWrite  []*domain.Reservation
+	toDelete []*domain.Reservation
+}
+
+type ReconcileItem struct {
+	ReservationID string                   `json:"reservation_id"`
+	StartTime     time.Time                `json:"start_time"`
+	Status        domain.ReservationStatus `json:"status"`
+	Version       int                      `json:"version"`
+	ExpiresAt     *time.Time               `json:"expires_at,omitempty"`
+}
+
+type ReconcileMismatch struct {
+	ReservationID string        `json:"reservation_id"`
+	Fields        []string      `json:"fields"`
+	Postgres      ReconcileItem `json:"postgres"`
+	ReadModel     ReconcileItem `json:"read_model"`
+}
+
+// runReconcile implements `worker reconcile [-event ID] [-start T] [-end T] [-repair]`.
+//
+// It compares Postgres with the read model and prints one JSON report per
+// event. Without -event every event in the catalog is checked. The exit
+// status is 2 when drift remains, including items a repair skipped because
+// they changed since they were read, so the command can run from cron.
+func runReconcile(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	eventID := fs.String("event", "", "event to check (default: every event in the catalog)")
+	startFlag := fs.String("start", "", "window start, RFC3339 (default: unbounded)")
+	endFlag := fs.String("end", "", "window end, RFC3339, exclusive (default: unbounded)")
+	repair := fs.Bool("repair", false, "rewrite missing and mismatched items and delete extra ones")
+	fs.Parse(args)
+
+	start, end := time.Unix(0, 0).UTC(), time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	var err error
+	if *startFlag != "" {
+		if start, err = time.Parse(time.RFC3339, *startFlag); err != nil {
+			log.Fatalf("-start: %v", err)
+		}
+	}
+	if *endFlag != "" {
+		if end, err = time.Parse(time.RFC3339, *endFlag); err != nil {
+			log.Fatalf("-end: %v", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to open DB driver: %v", err)
+	}
+	defer db.Close()
+
+	dynamoClient, err := bootstrap.NewDynamoDBClient(ctx, cfg.AWS)
+	if err != nil {
+		log.Fatalf("unable to load SDK config, %v", err)
+	}
+
+	source := repositories.NewPostgresReservationRepository(db)
+	readModel := repositories.NewDynamoDBReservationRepository(dynamoClient, cfg.DynamoDBTable)
+
+	eventIDs := []string{*eventID}
+	if *eventID == "" {
+		events, err := repositories.NewPostgresEventRepository(db).List(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list events: %v", err)
+		}
+		eventIDs = eventIDs[:0]
+		for _, event := range events {
+			eventIDs = append(eventIDs, event.ID)
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	drifted := false
+	for _, id := range eventIDs {
+		want, err := source.GetAllByEventAndRange(ctx, id, start, end)
+		if err != nil {
+			log.Fatalf("Failed to read reservations of event %s: %v", id, err)
+		}
+		got, err := readModel.ListAllByEvent(ctx, id, start, end)
+		if err != nil {
+			log.Fatalf("Failed to read the read model of event %s: %v", id, err)
+		}
+
+		report := reconcile(id, start, end, want, got, time.Now())
+		if *repair {
+			report.Repaired, report.Skipped = repairReadModel(ctx, readModel, report)
+		}
+		if report.Repaired < len(report.toWrite)+len(report.toDelete) {
+			drifted = true
+		}
+		enc.Encode(report)
+	}
+
+	if drifted {
+		os.Exit(2)
+	}
+}
+
+// reconcile diffs the expected read model, derived from Postgres with the
+// worker's projection, against the items actually stored. Items are
+// matched on reservation id and start time because the start time is
+// part of the key: an item left at an old start time counts as extra.
+// Items to write are stamped as updated at now.
+func reconcile(eventID string, start, end time.Time, want, got []*domain.Reservation, now time.Time) *ReconcileReport {
+	report := &ReconcileReport{
+		EventID:    eventID,
+		Start:      start,
+		End:        end,
+		Checked:    len(want),
+		Missing:    []ReconcileItem{},
+		Extra:      []ReconcileItem{},
+		Mismatched: []ReconcileMismatch{},
+	}
+
+	stored := make(map[string]*domain.Reservation, len(got))
+	for _, res := range got {
+		stored[readModelIdentity(res)] = res
+	}
+
+	for _, res := range want {
+		expected := messaging.ProjectSnapshot(domain.NewReservationSnapshot(res), res.Version, now)
+		key := readModelIdentity(expected)
+
+		actual, ok := stored[key]
+		if !ok {
+			report.Missing = append(report.Missing, reconcileItem(expected))
+			report.toWrite = append(report.toWrite, expected)
+			continue
+		}
+		delete(stored, key)
+
+		if fields := differingFields(expected, actual); len(fields) > 0 {
+			report.Mismatched = append(report.Mismatched, ReconcileMismatch{
+				ReservationID: expected.ID,
+				Fields:        fields,
+				Postgres:      reconcileItem(expected),
+				ReadModel:     reconcileItem(actual),
+			})
+			report.toWrite = append(report.toWrite, expected)
+		}
+	}
+
+	for _, res := range stored {
+		report.toDelete = append(report.toDelete, res)
+	}
+	sort.Slice(report.toDelete, func(i, j int) bool { return report.toDelete[i].ID < report.toDelete[j].ID })
+	for _, res := range report.toDelete {
+		report.Extra = append(report.Extra, reconcileItem(res))
+	}
+	return report
+}
+
+// repairReadModel writes every missing and mismatched item and deletes the
+// extra ones, returning how many were fixed and how many were skipped.
+// Both are conditioned on the versions seen by reconcile, so a change the
+// worker projects in the meantime wins and the repair can run during live
+// traffic. A skipped write fixed nothing, so it still counts as drift
+// until a later run finds the item consistent.
+func repairReadModel(ctx context.Context, readModel *repositories.DynamoDBReservationRepository, report *ReconcileReport) (repaired, skipped int) {
+	for _, res := range report.toWrite {
+		err := readModel.RepairReadModel(ctx, res)
+		if errors.Is(err, repositories.ErrReadModelSuperseded) {
+			skipped++
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to repair reservation %s: %v", res.ID, err)
+			continue
+		}
+		repaired++
+	}
+	for _, res := range report.toDelete {
+		err := readModel.DeleteReadModel(ctx, res)
+		if errors.Is(err, repositories.ErrReadModelSuperseded) {
+			skipped++
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to delete extra item for reservation %s: %v", res.ID, err)
+			continue
+		}
+		repaired++
+	}
+	return repaired, skipped
+}
+
+func differingFields(expected, actual *domain.Reservation) []string {
+	var fields []string
+	if expected.Status != actual.Status {
+		fields = append(fields, "status")
+	}
+	if expected.Version != actual.Version {
+		fields = append(fields, "version")
+	}
+	if expected.TicketCount != actual.TicketCount {
+		fields = append(fields, "ticket_count")
+	}
+	if !expected.EndTime.Truncate(time.Second).Equal(actual.EndTime.Truncate(time.Second)) {
+		fields = append(fields, "end_time")
+	}
+	if expected.UserID != actual.UserID {
+		fields = append(fields, "user_id")
+	}
+	if !sameInstant(expected.ExpiresAt, actual.ExpiresAt) {
+		fields = append(fields, "expires_at")
+	}
+	return fields
+}
+
+// sameInstant compares optional times at the read model's second precision.
+func sameInstant(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Truncate(time.Second).Equal(b.Truncate(time.Second))
+}
+
+// readModelIdentity mirrors the read model key, which stores start times
+// with second precision.
+func readModelIdentity(res *domain.Reservation) string {
+	return res.ID + "#" + res.StartTime.UTC().Truncate(time.Second).Format(time.RFC3339)
+}
+
+func reconcileItem(res *domain.Reservation) ReconcileItem {
+	return ReconcileItem{
+		ReservationID: res.ID,
+		StartTime:     res.StartTime.UTC(),
+		Status:        res.Status,
+		Version:       res.Version,
+		ExpiresAt:     res.ExpiresAt,
+	}
+}
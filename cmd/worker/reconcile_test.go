@@ -0,0 +1,139 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+)
+
+func TestReconcile(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	start := time.Date(2030, 1, 10, 18, 0, 0, 0, time.UTC)
+	expires := now.Add(15 * time.Minute)
+
+	reservation := func(id string, start time.Time, status domain.ReservationStatus, version int) *domain.Reservation {
+		return &domain.Reservation{
+			ID:          id,
+			UserID:      "user-1",
+			EventID:     "event-1",
+			StartTime:   start,
+			EndTime:     start.Add(time.Hour),
+			TicketCount: 2,
+			Status:      status,
+			Version:     version,
+		}
+	}
+	held := func(id string, expiresAt time.Time) *domain.Reservation {
+		res := reservation(id, start, domain.StatusHeld, 1)
+		res.ExpiresAt = &expiresAt
+		return res
+	}
+	// Postgres keeps sub-second times the read model drops
+	subSecond := func(res *domain.Reservation) *domain.Reservation {
+		item := *res
+		item.StartTime = res.StartTime.Add(400 * time.Millisecond)
+		item.EndTime = res.EndTime.Add(400 * time.Millisecond)
+		return &item
+	}
+
+	type mismatch struct {
+		id     string
+		fields []string
+	}
+	tests := []struct {
+		name           string
+		want, got      []*domain.Reservation
+		wantMissing    []string
+		wantExtra      []string
+		wantMismatched []mismatch
+	}{
+		{
+			name: "in sync",
+			want: []*domain.Reservation{subSecond(reservation("res-1", start, domain.StatusBooked, 1)), held("res-2", expires)},
+			got:  []*domain.Reservation{reservation("res-1", start, domain.StatusBooked, 1), held("res-2", expires)},
+		},
+		{
+			name:        "missing",
+			want:        []*domain.Reservation{reservation("res-1", start, domain.StatusBooked, 1)},
+			wantMissing: []string{"res-1"},
+		},
+		{
+			name:      "extra",
+			got:       []*domain.Reservation{reservation("res-2", start, domain.StatusBooked, 1), reservation("res-1", start, domain.StatusBooked, 1)},
+			wantExtra: []string{"res-1", "res-2"},
+		},
+		{
+			name: "mismatched",
+			want: []*domain.Reservation{reservation("res-1", start, domain.StatusCancelled, 2)},
+			got:  []*domain.Reservation{reservation("res-1", start, domain.StatusBooked, 1)},
+			wantMismatched: []mismatch{
+				{"res-1", []string{"status", "version"}},
+			},
+		},
+		{
+			name: "mismatched expiry",
+			want: []*domain.Reservation{held("res-1", expires), held("res-2", expires)},
+			got:  []*domain.Reservation{held("res-1", expires.Add(time.Minute)), reservation("res-2", start, domain.StatusHeld, 1)},
+			wantMismatched: []mismatch{
+				{"res-1", []string{"expires_at"}},
+				{"res-2", []string{"expires_at"}},
+			},
+		},
+		{
+			// The start time is part of the key, so an item left at the old
+			// time is extra and the new one missing
+			name:        "moved",
+			want:        []*domain.Reservation{reservation("res-1", start.Add(time.Hour), domain.StatusBooked, 2)},
+			got:         []*domain.Reservation{reservation("res-1", start, domain.StatusBooked, 1)},
+			wantMissing: []string{"res-1"},
+			wantExtra:   []string{"res-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := reconcile("event-1", start, start.Add(24*time.Hour), tt.want, tt.got, now)
+
+			if report.Checked != len(tt.want) {
+				t.Errorf("checked = %d; want %d", report.Checked, len(tt.want))
+			}
+			if got := itemIDs(report.Missing); !slices.Equal(got, tt.wantMissing) {
+				t.Errorf("missing = %v; want %v", got, tt.wantMissing)
+			}
+			if got := itemIDs(report.Extra); !slices.Equal(got, tt.wantExtra) {
+				t.Errorf("extra = %v; want %v", got, tt.wantExtra)
+			}
+			if len(report.Mismatched) != len(tt.wantMismatched) {
+				t.Fatalf("mismatched = %+v; want %+v", report.Mismatched, tt.wantMismatched)
+			}
+			for i, want := range tt.wantMismatched {
+				got := report.Mismatched[i]
+				if got.ReservationID != want.id || !slices.Equal(got.Fields, want.fields) {
+					t.Errorf("mismatch %d = %s %v; want %s %v", i, got.ReservationID, got.Fields, want.id, want.fields)
+				}
+			}
+
+			// A repair writes what is missing or wrong and deletes the rest
+			if wantWrites := len(tt.wantMissing) + len(tt.wantMismatched); len(report.toWrite) != wantWrites {
+				t.Errorf("%d items to write; want %d", len(report.toWrite), wantWrites)
+			}
+			if len(report.toDelete) != len(tt.wantExtra) {
+				t.Errorf("%d items to delete; want %d", len(report.toDelete), len(tt.wantExtra))
+			}
+			for _, res := range report.toWrite {
+				if !res.UpdatedAt.Equal(now) {
+					t.Errorf("item to write for %s stamped %s; want %s", res.ID, res.UpdatedAt, now)
+				}
+			}
+		})
+	}
+}
+
+func itemIDs(items []ReconcileItem) []string {
+	var ids []string
+	for _, item := range items {
+		ids = append(ids, item.ReservationID)
+	}
+	return ids
+}
@@ -0,0 +1,3 @@
+DELETE FROM events WHERE id IN ('event-1', 'event-2', 'event-3', 'event-4', 'event-5', 'event-6');
+ALTER TABLE events DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE events ALTER COLUMN id TYPE UUID USING id::uuid;
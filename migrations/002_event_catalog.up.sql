@@ -0,0 +1,13 @@
+-- Event ids are opaque strings (the seeded catalog predates UUIDs)
+ALTER TABLE events ALTER COLUMN id TYPE TEXT;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW();
+
+-- Seed the catalog that used to be hardcoded in the event handler
+INSERT INTO events (id, name, venue, timezone) VALUES
+    ('event-1', 'Late Night Comedy', 'The Basement Club', 'UTC'),
+    ('event-2', 'Jazz Quartet', 'Blue Note Lounge', 'UTC'),
+    ('event-3', 'Indie Film Festival', 'Cinema 4', 'UTC'),
+    ('event-4', 'Tech Conference 2026', 'Convention Center', 'UTC'),
+    ('event-5', 'Live Podcast Recording', 'Studio A', 'UTC'),
+    ('event-6', 'Charity Gala', 'Grand Ballroom', 'UTC')
+ON CONFLICT (id) DO NOTHING;
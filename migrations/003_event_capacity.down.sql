@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_reservations_event_status;
+ALTER TABLE events DROP COLUMN IF EXISTS slot_capacity;
+ALTER TABLE events DROP COLUMN IF EXISTS capacity;
@@ -0,0 +1,5 @@
+-- Zero means unlimited, which keeps existing events bookable
+ALTER TABLE events ADD COLUMN IF NOT EXISTS capacity INT NOT NULL DEFAULT 0;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS slot_capacity INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_reservations_event_status ON reservations (event_id, status);
@@ -0,0 +1,4 @@
+-- Set while a reservation is HELD; the worker expires holds past it
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_reservations_held_expiry ON reservations (expires_at) WHERE status = 'HELD';
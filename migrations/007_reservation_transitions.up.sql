@@ -0,0 +1,12 @@
+-- Audit trail of status changes; the state machine lives in pkg/core/domain
+CREATE TABLE IF NOT EXISTS reservation_transitions (
+    id BIGSERIAL PRIMARY KEY,
+    reservation_id TEXT NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+    from_status TEXT, -- NULL for the transition that created the reservation
+    to_status TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    reason TEXT,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_reservation_transitions_reservation ON reservation_transitions (reservation_id, id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_outbox_pending;
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (id) WHERE dispatched_at IS NULL;
+
+ALTER TABLE outbox DROP COLUMN IF EXISTS parked_at;
+ALTER TABLE outbox DROP COLUMN IF EXISTS max_attempts;
@@ -0,0 +1,9 @@
+-- Messages that can never be published, or that failed max_attempts times,
+-- are parked instead of blocking the ones behind them. Requeue a parked
+-- message once its cause is fixed with
+--   UPDATE outbox SET parked_at = NULL, attempts = 0 WHERE id = ...;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS max_attempts INT NOT NULL DEFAULT 25;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS parked_at TIMESTAMP WITH TIME ZONE;
+
+DROP INDEX IF EXISTS idx_outbox_pending;
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (id) WHERE dispatched_at IS NULL AND parked_at IS NULL;
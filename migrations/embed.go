@@ -0,0 +1,9 @@
+// Package migrations embeds the versioned SQL schema migrations so binaries
+// don't depend on the working directory. Files are named
+// <version>_<name>.up.sql with an optional matching .down.sql.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
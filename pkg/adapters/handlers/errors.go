@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+)
+
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// Error codes are part of the API contract; clients should branch on these
+// rather than on the human-readable message.
+const (
+	CodeBadRequest       = "BAD_REQUEST"
+	CodeUnauthorized     = "UNAUTHORIZED"
+	CodeForbidden        = "FORBIDDEN"
+	CodeValidationFailed = "VALIDATION_FAILED"
+	CodeNotFound         = "NOT_FOUND"
+	CodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+	CodeVersionConflict  = "VERSION_CONFLICT"
+	CodeInvalidState     = "INVALID_STATE"
+	CodeCapacityExceeded = "CAPACITY_EXCEEDED"
+	CodeHoldExpired      = "HOLD_EXPIRED"
+	CodeUnavailable      = "SERVICE_UNAVAILABLE"
+	CodeInternal         = "INTERNAL_ERROR"
+)
+
+type ErrorResponse struct {
+	Code          string      `json:"code"`
+	Message       string      `json:"message"`
+	Details       interface{} `json:"details,omitempty"`
+	CorrelationID string      `json:"correlation_id,omitempty"`
+}
+
+var errorMappings = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{domain.ErrInvalidTime, http.StatusUnprocessableEntity, CodeValidationFailed},
+	{domain.ErrPastTime, http.StatusUnprocessableEntity, CodeValidationFailed},
+	{domain.ErrDuration, http.StatusUnprocessableEntity, CodeValidationFailed},
+	{domain.ErrInvalidTicketCount, http.StatusUnprocessableEntity, CodeValidationFailed},
+	{domain.ErrInvalidEventName, http.StatusUnprocessableEntity, CodeValidationFailed},
+	{domain.ErrInvalidTimezone, http.StatusUnprocessableEntity, CodeValidationFailed},
+	{domain.ErrInvalidCapacity, http.StatusUnprocessableEntity, CodeValidationFailed},
+	{ports.ErrInvalidPageToken, http.StatusBadRequest, CodeBadRequest},
+	{domain.ErrNotFound, http.StatusNotFound, CodeNotFound},
+	{domain.ErrEventNotFound, http.StatusNotFound, CodeNotFound},
+	{domain.ErrVersionConflict, http.StatusConflict, CodeVersionConflict},
+	{domain.ErrAlreadyCancelled, http.StatusConflict, CodeInvalidState},
+	{domain.ErrCapacityExceeded, http.StatusConflict, CodeCapacityExceeded},
+	{domain.ErrNotModifiable, http.StatusConflict, CodeInvalidState},
+	{domain.ErrNotStarted, http.StatusConflict, CodeInvalidState},
+	{domain.ErrHoldExpired, http.StatusConflict, CodeHoldExpired},
+}
+
+// TransitionErrorDetails tells clients which status change was refused.
+type TransitionErrorDetails struct {
+	From domain.ReservationStatus `json:"from"`
+	To   domain.ReservationStatus `json:"to"`
+}
+
+// WriteError translates a service error into the JSON error envelope.
+// Unknown errors are logged and reported as a generic 500 so internals
+// such as SQL errors never reach the client.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	var invalid *domain.ErrInvalidTransition
+	if errors.As(err, &invalid) {
+		WriteErrorResponse(w, r, http.StatusConflict, CodeInvalidState, err.Error(), TransitionErrorDetails{From: invalid.From, To: invalid.To})
+		return
+	}
+
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			WriteErrorResponse(w, r, m.status, m.code, err.Error(), nil)
+			return
+		}
+	}
+
+	log.Printf("ERROR: %s %s [%s]: %v", r.Method, r.URL.Path, r.Header.Get(CorrelationIDHeader), err)
+	WriteErrorResponse(w, r, http.StatusInternalServerError, CodeInternal, "Internal server error", nil)
+}
+
+func WriteErrorResponse(w http.ResponseWriter, r *http.Request, status int, code, message string, details interface{}) {
+	writeJSON(w, status, ErrorResponse{
+		Code:          code,
+		Message:       message,
+		Details:       details,
+		CorrelationID: r.Header.Get(CorrelationIDHeader),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
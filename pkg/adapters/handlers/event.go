@@ -3,31 +3,83 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
 )
 
-type Event struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Venue string `json:"venue"`
+type EventHandler struct {
+	service ports.EventService
 }
 
-type EventHandler struct{}
+func NewEventHandler(service ports.EventService) *EventHandler {
+	return &EventHandler{service: service}
+}
 
-func NewEventHandler() *EventHandler {
-	return &EventHandler{}
+type EventRequest struct {
+	Name         string `json:"name"`
+	Venue        string `json:"venue"`
+	Timezone     string `json:"timezone"`
+	Capacity     int    `json:"capacity"`
+	SlotCapacity int    `json:"slot_capacity"`
 }
 
 func (h *EventHandler) List(w http.ResponseWriter, r *http.Request) {
-	// Matching data from TicketBookingFlow.tsx
-	events := []Event{
-		{ID: "event-1", Name: "Late Night Comedy", Venue: "The Basement Club"},
-		{ID: "event-2", Name: "Jazz Quartet", Venue: "Blue Note Lounge"},
-		{ID: "event-3", Name: "Indie Film Festival", Venue: "Cinema 4"},
-		{ID: "event-4", Name: "Tech Conference 2026", Venue: "Convention Center"},
-		{ID: "event-5", Name: "Live Podcast Recording", Venue: "Studio A "},
-		{ID: "event-6", Name: "Charity Gala", Venue: "Grand Ballroom"},
+	events, err := h.service.List(r.Context())
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, events)
+}
+
+func (h *EventHandler) Get(w http.ResponseWriter, r *http.Request) {
+	event, err := h.service.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, event)
+}
+
+func (h *EventHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req EventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	event, err := h.service.Create(r.Context(), req.Name, req.Venue, req.Timezone, req.Capacity, req.SlotCapacity)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, event)
+}
+
+func (h *EventHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req EventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	event, err := h.service.Update(r.Context(), r.PathValue("id"), req.Name, req.Venue, req.Timezone, req.Capacity, req.SlotCapacity)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, event)
+}
+
+func (h *EventHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Delete(r.Context(), r.PathValue("id")); err != nil {
+		WriteError(w, r, err)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(events)
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	HealthOK          = "ok"
+	HealthDegraded    = "degraded"
+	HealthUnavailable = "unavailable"
+)
+
+// HealthCheck probes a single dependency. A failing Required check marks
+// the instance unready; optional checks are reported but only degrade it.
+type HealthCheck struct {
+	Name     string
+	Required bool
+	// Check returns an optional human-readable detail, such as a version
+	// or a backlog size, alongside any failure.
+	Check func(ctx context.Context) (string, error)
+}
+
+type HealthReport struct {
+	Status string              `json:"status"`
+	Checks []HealthCheckResult `json:"checks,omitempty"`
+}
+
+type HealthCheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Required  bool    `json:"required"`
+	LatencyMS float64 `json:"latency_ms"`
+	Detail    string  `json:"detail,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type HealthHandler struct {
+	checks []HealthCheck
+
+	// Timeout bounds the whole readiness probe; checks run concurrently.
+	Timeout time.Duration
+}
+
+func NewHealthHandler(checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks, Timeout: 2 * time.Second}
+}
+
+// Live reports that the process is up and serving HTTP. It never touches
+// dependencies, so a slow database does not get the instance restarted.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, HealthReport{Status: HealthOK})
+}
+
+// Ready runs every check and answers 503 when a required one fails, so
+// the orchestrator stops routing traffic to this instance.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report := h.Run(r.Context())
+
+	status := http.StatusOK
+	if report.Status == HealthUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+func (h *HealthHandler) Run(ctx context.Context) HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	results := make([]HealthCheckResult, len(h.checks))
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := HealthReport{Status: HealthOK, Checks: results}
+	for _, result := range results {
+		if result.Status == HealthOK {
+			continue
+		}
+		if result.Required {
+			report.Status = HealthUnavailable
+			break
+		}
+		report.Status = HealthDegraded
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, check HealthCheck) HealthCheckResult {
+	start := time.Now()
+	detail, err := check.Check(ctx)
+
+	result := HealthCheckResult{
+		Name:      check.Name,
+		Status:    HealthOK,
+		Required:  check.Required,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Detail:    detail,
+	}
+	if err != nil {
+		result.Status = HealthUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	CodeIdempotencyKeyReused  = "IDEMPOTENCY_KEY_REUSED"
+	CodeIdempotencyInProgress = "IDEMPOTENCY_IN_PROGRESS"
+)
+
+// Idempotent returns middleware that honours the Idempotency-Key header.
+// The first request with a key runs normally and its response is stored;
+// retries with the same body get the stored response back, and reusing the
+// key with a different body is rejected with 422. Requests without the
+// header, or with a nil store, pass straight through.
+func Idempotent(store ports.IdempotencyStore, ttl time.Duration) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || store == nil {
+				next(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				WriteErrorResponse(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid request body", err.Error())
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			sum := sha256.Sum256(body)
+			hash := hex.EncodeToString(sum[:])
+
+			// Keys are per user and per route so clients can't collide
+			scopedKey := domain.SubjectFromContext(r.Context()) + " " + r.Method + " " + routePath(r.URL.Path) + " " + key
+
+			existing, err := store.Reserve(r.Context(), scopedKey, hash, ttl)
+			if err != nil {
+				WriteError(w, r, err)
+				return
+			}
+
+			if existing != nil {
+				switch {
+				case existing.RequestHash != hash:
+					WriteErrorResponse(w, r, http.StatusUnprocessableEntity, CodeIdempotencyKeyReused,
+						"Idempotency-Key was already used with a different request body", nil)
+				case existing.StatusCode == 0:
+					WriteErrorResponse(w, r, http.StatusConflict, CodeIdempotencyInProgress,
+						"A request with this Idempotency-Key is still being processed", nil)
+				default:
+					w.Header().Set("Content-Type", "application/json")
+					w.Header().Set(IdempotentReplayedHeader, "true")
+					w.WriteHeader(existing.StatusCode)
+					w.Write(existing.ResponseBody)
+				}
+				return
+			}
+
+			// Record the outcome even if the client has gone away meanwhile
+			ctx := context.WithoutCancel(r.Context())
+
+			// Unless the response is stored below, the key is released, so a
+			// panic in next or a failed Complete never leaves it in flight
+			// for the whole TTL
+			stored := false
+			defer func() {
+				if stored {
+					return
+				}
+				if err := store.Release(ctx, scopedKey); err != nil {
+					log.Printf("Failed to release idempotency key: %v", err)
+				}
+			}()
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next(rec, r)
+
+			// Server errors are not remembered so the client can retry them
+			if rec.status >= http.StatusInternalServerError {
+				return
+			}
+			if err := store.Complete(ctx, scopedKey, rec.status, rec.body.Bytes()); err != nil {
+				log.Printf("Failed to store idempotent response: %v", err)
+				return
+			}
+			stored = true
+		}
+	}
+}
+
+// routePath drops the /api prefix every route is also served under, so a
+// retry through either path replays the same key.
+func routePath(path string) string {
+	if rest, ok := strings.CutPrefix(path, "/api"); ok && strings.HasPrefix(rest, "/") {
+		return rest
+	}
+	return path
+}
+
+// responseRecorder passes the response through while keeping a copy.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,101 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/handlers"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/memory"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+)
+
+// failingComplete is an idempotency store whose Complete always fails.
+type failingComplete struct {
+	ports.IdempotencyStore
+}
+
+func (failingComplete) Complete(ctx context.Context, key string, statusCode int, body []byte) error {
+	return errors.New("store unavailable")
+}
+
+func idempotentRequest() *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/reservations", strings.NewReader(`{"event_id":"event-1"}`))
+	r.Header.Set(handlers.IdempotencyKeyHeader, "key-1")
+	return r
+}
+
+func TestIdempotentReleasesKeyAfterPanic(t *testing.T) {
+	store := memory.NewIdempotencyStore(memory.NewStore())
+	calls := 0
+	handler := handlers.Idempotent(store, time.Hour)(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("panic was swallowed")
+			}
+		}()
+		handler(httptest.NewRecorder(), idempotentRequest())
+	}()
+
+	// The retry runs instead of being told the first one is in flight
+	w := httptest.NewRecorder()
+	handler(w, idempotentRequest())
+	if w.Code != http.StatusCreated || calls != 2 {
+		t.Fatalf("retry = %d after %d calls; want %d after 2", w.Code, calls, http.StatusCreated)
+	}
+
+	// and its response is now replayed
+	w = httptest.NewRecorder()
+	handler(w, idempotentRequest())
+	if w.Code != http.StatusCreated || w.Header().Get(handlers.IdempotentReplayedHeader) != "true" || calls != 2 {
+		t.Fatalf("replay = %d (replayed %q) after %d calls; want a replayed %d", w.Code, w.Header().Get(handlers.IdempotentReplayedHeader), calls, http.StatusCreated)
+	}
+}
+
+func TestIdempotentReleasesKeyWhenResponseIsNotStored(t *testing.T) {
+	store := failingComplete{memory.NewIdempotencyStore(memory.NewStore())}
+	calls := 0
+	handler := handlers.Idempotent(store, time.Hour)(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	for i := 1; i <= 2; i++ {
+		w := httptest.NewRecorder()
+		handler(w, idempotentRequest())
+		if w.Code != http.StatusCreated || calls != i {
+			t.Fatalf("request %d = %d after %d calls; want %d", i, w.Code, calls, http.StatusCreated)
+		}
+	}
+}
+
+func TestIdempotentKeyIsSharedByAPIPrefixedPath(t *testing.T) {
+	store := memory.NewIdempotencyStore(memory.NewStore())
+	calls := 0
+	handler := handlers.Idempotent(store, time.Hour)(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	handler(httptest.NewRecorder(), idempotentRequest())
+
+	r := idempotentRequest()
+	r.URL.Path = "/api/reservations"
+	w := httptest.NewRecorder()
+	handler(w, r)
+	if w.Header().Get(handlers.IdempotentReplayedHeader) != "true" || calls != 1 {
+		t.Fatalf("retry through /api ran the handler (%d calls, replayed %q); want a replay", calls, w.Header().Get(handlers.IdempotentReplayedHeader))
+	}
+}
@@ -1,19 +1,29 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
 	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
 )
 
+// NextPageTokenHeader carries the token for the following page of a list,
+// keeping the response body a plain array for existing clients.
+const NextPageTokenHeader = "X-Next-Page-Token"
+
 type ReservationHandler struct {
 	service ports.ReservationService
+	clock   ports.Clock
 }
 
-func NewReservationHandler(service ports.ReservationService) *ReservationHandler {
-	return &ReservationHandler{service: service}
+func NewReservationHandler(service ports.ReservationService, clock ports.Clock) *ReservationHandler {
+	return &ReservationHandler{service: service, clock: clock}
 }
 
 type CreateReservationRequest struct {
@@ -24,16 +34,131 @@ type CreateReservationRequest struct {
 	TicketCount int       `json:"ticket_count"`
 }
 
+// ModifyReservationRequest carries a partial update; omitted fields keep
+// their current value. Version, when set, must match the stored version.
+type ModifyReservationRequest struct {
+	StartTime   *time.Time `json:"start_time"`
+	EndTime     *time.Time `json:"end_time"`
+	TicketCount *int       `json:"ticket_count"`
+	Version     int        `json:"version"`
+}
+
 func (h *ReservationHandler) Create(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		WriteErrorResponse(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed", nil)
+		return
+	}
+
+	req, userID, ok := decodeCreateRequest(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.service.Create(r.Context(), userID, req.EventID, req.StartTime, req.EndTime, req.TicketCount)
+	if err != nil {
+		WriteError(w, r, err)
 		return
 	}
 
+	writeJSON(w, http.StatusCreated, res)
+}
+
+// Hold reserves seats during checkout. The response carries expires_at;
+// the client must confirm before then or the seats are released.
+func (h *ReservationHandler) Hold(w http.ResponseWriter, r *http.Request) {
+	req, userID, ok := decodeCreateRequest(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.service.Hold(r.Context(), userID, req.EventID, req.StartTime, req.EndTime, req.TicketCount)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, res)
+}
+
+func (h *ReservationHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, func(ctx context.Context, id, _ string) (*domain.Reservation, error) {
+		return h.service.Confirm(ctx, id)
+	})
+}
+
+// TransitionRequest is the optional body of status changes such as cancel
+// and no-show.
+type TransitionRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *ReservationHandler) CheckIn(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, func(ctx context.Context, id, _ string) (*domain.Reservation, error) {
+		return h.service.CheckIn(ctx, id)
+	})
+}
+
+func (h *ReservationHandler) Complete(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, func(ctx context.Context, id, _ string) (*domain.Reservation, error) {
+		return h.service.Complete(ctx, id)
+	})
+}
+
+func (h *ReservationHandler) NoShow(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.service.MarkNoShow)
+}
+
+// transition serves the endpoints that move a reservation to another
+// status, passing the optional reason from the body to change.
+func (h *ReservationHandler) transition(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, id, reason string) (*domain.Reservation, error)) {
+	id := r.PathValue("id")
+	if id == "" {
+		WriteErrorResponse(w, r, http.StatusBadRequest, CodeBadRequest, "Missing reservation id", nil)
+		return
+	}
+
+	var req TransitionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteErrorResponse(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	res, err := change(r.Context(), id, req.Reason)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Transitions lists the status history of a reservation, oldest first.
+func (h *ReservationHandler) Transitions(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		WriteErrorResponse(w, r, http.StatusBadRequest, CodeBadRequest, "Missing reservation id", nil)
+		return
+	}
+
+	transitions, err := h.service.Transitions(r.Context(), id)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	if transitions == nil {
+		transitions = []domain.Transition{}
+	}
+
+	writeJSON(w, http.StatusOK, transitions)
+}
+
+// decodeCreateRequest reads a booking request and resolves the user it is
+// for. It writes the error response itself and reports false on failure.
+func decodeCreateRequest(w http.ResponseWriter, r *http.Request) (CreateReservationRequest, string, bool) {
 	var req CreateReservationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
+		WriteErrorResponse(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid request body", err.Error())
+		return req, "", false
 	}
 
 	// Default to 1 ticket if not specified
@@ -41,14 +166,13 @@ func (h *ReservationHandler) Create(w http.ResponseWriter, r *http.Request) {
 		req.TicketCount = 1
 	}
 
-	res, err := h.service.Create(r.Context(), req.UserID, req.EventID, req.StartTime, req.EndTime, req.TicketCount)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	// The token subject wins over the payload; user_id in the body is only
+	// honoured when authentication is disabled (local development).
+	userID := req.UserID
+	if subject := domain.SubjectFromContext(r.Context()); subject != "" {
+		userID = subject
 	}
-
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(res)
+	return req, userID, true
 }
 
 func (h *ReservationHandler) Get(w http.ResponseWriter, r *http.Request) {
@@ -59,7 +183,7 @@ func (h *ReservationHandler) Get(w http.ResponseWriter, r *http.Request) {
 		startStr := r.URL.Query().Get("start_date")
 		endStr := r.URL.Query().Get("end_date")
 
-		now := time.Now()
+		now := h.clock.Now()
 		// Default to today
 		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 		end := start.Add(24 * time.Hour)
@@ -75,31 +199,69 @@ func (h *ReservationHandler) Get(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		res, err := h.service.ListByEvent(r.Context(), eventID, start, end)
+		// Pagination only applies when served from the read model
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		pageToken := r.URL.Query().Get("page_token")
+
+		page, err := h.service.ListByEvent(r.Context(), eventID, start, end, limit, pageToken)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			WriteError(w, r, err)
 			return
 		}
-		json.NewEncoder(w).Encode(res)
+		if page.NextPageToken != "" {
+			w.Header().Set(NextPageTokenHeader, page.NextPageToken)
+		}
+		writeJSON(w, http.StatusOK, page.Items)
 		return
 	}
 
 	// Get by ID
 	id := r.URL.Query().Get("id")
 	if id == "" {
-		http.Error(w, "Missing id or event_id", http.StatusBadRequest)
+		WriteErrorResponse(w, r, http.StatusBadRequest, CodeBadRequest, "Missing id or event_id", nil)
 		return
 	}
 
 	res, err := h.service.Get(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteError(w, r, err)
 		return
 	}
 	if res == nil {
-		http.Error(w, "Not found", http.StatusNotFound)
+		WriteErrorResponse(w, r, http.StatusNotFound, CodeNotFound, "Not found", nil)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Cancel accepts an optional {"reason": ...} body.
+func (h *ReservationHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.service.Cancel)
+}
+
+func (h *ReservationHandler) Modify(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		WriteErrorResponse(w, r, http.StatusBadRequest, CodeBadRequest, "Missing reservation id", nil)
+		return
+	}
+
+	var req ModifyReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, r, http.StatusBadRequest, CodeBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	res, err := h.service.Modify(r.Context(), id, req.Version, domain.ReservationChanges{
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		TicketCount: req.TicketCount,
+	})
+	if err != nil {
+		WriteError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(res)
+	writeJSON(w, http.StatusOK, res)
 }
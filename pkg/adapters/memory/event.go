@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+)
+
+type EventRepository struct {
+	store *Store
+}
+
+func NewEventRepository(store *Store) *EventRepository {
+	return &EventRepository{store: store}
+}
+
+func (r *EventRepository) Save(ctx context.Context, event *domain.Event) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.events[event.ID]; ok {
+		return ErrDuplicateID
+	}
+	s.events[event.ID] = *event
+	return nil
+}
+
+func (r *EventRepository) Update(ctx context.Context, event *domain.Event) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.events[event.ID]
+	if !ok {
+		return domain.ErrEventNotFound
+	}
+	updated := *event
+	updated.CreatedAt = current.CreatedAt
+	s.events[event.ID] = updated
+	return nil
+}
+
+func (r *EventRepository) Delete(ctx context.Context, id string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.events[id]; !ok {
+		return domain.ErrEventNotFound
+	}
+	delete(s.events, id)
+	return nil
+}
+
+func (r *EventRepository) GetByID(ctx context.Context, id string) (*domain.Event, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, ok := s.events[id]
+	if !ok {
+		return nil, nil
+	}
+	return &event, nil
+}
+
+func (r *EventRepository) List(ctx context.Context) ([]*domain.Event, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]*domain.Event, 0, len(s.events))
+	for _, event := range s.events {
+		event := event
+		events = append(events, &event)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Name < events[j].Name })
+	return events, nil
+}
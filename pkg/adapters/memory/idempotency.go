@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+)
+
+type IdempotencyStore struct {
+	store *Store
+}
+
+func NewIdempotencyStore(store *Store) *IdempotencyStore {
+	return &IdempotencyStore{store: store}
+}
+
+func (i *IdempotencyStore) Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*ports.IdempotencyRecord, error) {
+	s := i.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if rec, ok := s.idempotency[key]; ok && !rec.ExpiresAt.Before(now) {
+		return &rec, nil
+	}
+
+	s.idempotency[key] = ports.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	return nil, nil
+}
+
+func (i *IdempotencyStore) Complete(ctx context.Context, key string, statusCode int, body []byte) error {
+	s := i.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec, ok := s.idempotency[key]; ok {
+		rec.StatusCode = statusCode
+		rec.ResponseBody = append([]byte(nil), body...)
+		s.idempotency[key] = rec
+	}
+	return nil
+}
+
+func (i *IdempotencyStore) Release(ctx context.Context, key string) error {
+	s := i.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.idempotency, key)
+	return nil
+}
@@ -0,0 +1,25 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/memory"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/repotest"
+)
+
+func TestReservationRepositoryContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Fixture {
+		store := memory.NewStore()
+		return repotest.Fixture{
+			Reservations: memory.NewReservationRepository(store),
+			Events:       memory.NewEventRepository(store),
+			Outbox:       memory.NewOutboxRepository(store),
+		}
+	})
+}
+
+func TestReadModelContract(t *testing.T) {
+	repotest.RunReadModel(t, func(t *testing.T) repotest.ReadModel {
+		return memory.NewReadModel()
+	})
+}
@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+)
+
+type OutboxRepository struct {
+	store *Store
+}
+
+func NewOutboxRepository(store *Store) *OutboxRepository {
+	return &OutboxRepository{store: store}
+}
+
+func (r *OutboxRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]ports.OutboxMessage, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var msgs []ports.OutboxMessage
+	for i := range s.outbox {
+		if len(msgs) == limit {
+			break
+		}
+		entry := &s.outbox[i]
+		if !entry.parkedAt.IsZero() || entry.lockedUntil.After(now) {
+			continue
+		}
+		entry.lockedUntil = now.Add(lease)
+		msgs = append(msgs, entry.msg)
+	}
+	return msgs, nil
+}
+
+// MarkDispatched drops the entry: nothing reads a dispatched message
+// again, and keeping them would grow the store for the life of the process.
+func (r *OutboxRepository) MarkDispatched(ctx context.Context, id int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.outbox {
+		if s.outbox[i].msg.ID == id {
+			s.outbox = append(s.outbox[:i], s.outbox[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id int64, reason string) error {
+	return r.store.updateOutbox(id, func(entry *outboxEntry) {
+		entry.msg.Attempts++
+		entry.lockedUntil = time.Time{}
+		entry.lastError = reason
+		if entry.msg.Attempts >= ports.OutboxMaxAttempts {
+			entry.parkedAt = time.Now()
+		}
+	})
+}
+
+func (r *OutboxRepository) Park(ctx context.Context, id int64, reason string) error {
+	return r.store.updateOutbox(id, func(entry *outboxEntry) {
+		entry.lockedUntil = time.Time{}
+		entry.lastError = reason
+		entry.parkedAt = time.Now()
+	})
+}
+
+func (r *OutboxRepository) Release(ctx context.Context, id int64) error {
+	return r.store.updateOutbox(id, func(entry *outboxEntry) {
+		entry.lockedUntil = time.Time{}
+	})
+}
+
+func (r *OutboxRepository) CountPending(ctx context.Context) (int64, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, entry := range s.outbox {
+		if entry.parkedAt.IsZero() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// appendOutbox records events with fresh ids. The caller must hold the lock.
+func (s *Store) appendOutbox(events []ports.OutboxMessage) {
+	for _, msg := range events {
+		s.nextOutboxID++
+		msg.ID = s.nextOutboxID
+		s.outbox = append(s.outbox, outboxEntry{msg: msg})
+	}
+}
+
+func (s *Store) updateOutbox(id int64, fn func(*outboxEntry)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.outbox {
+		if s.outbox[i].msg.ID == id {
+			fn(&s.outbox[i])
+			return nil
+		}
+	}
+	return nil
+}
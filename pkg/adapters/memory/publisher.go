@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+)
+
+// Publisher records every published envelope instead of sending it
+// anywhere. When a ReadModel is attached, reservation events are also
+// projected into it, standing in for the worker.
+type Publisher struct {
+	ReadModel *ReadModel
+
+	mu        sync.Mutex
+	published []domain.Envelope
+}
+
+func NewPublisher() *Publisher {
+	return &Publisher{}
+}
+
+func (p *Publisher) Publish(ctx context.Context, event domain.Envelope) error {
+	p.mu.Lock()
+	p.published = append(p.published, event)
+	p.mu.Unlock()
+
+	if p.ReadModel != nil {
+		return p.ReadModel.Apply(event)
+	}
+	return nil
+}
+
+// Published returns a copy of the envelopes published so far, oldest first.
+func (p *Publisher) Published() []domain.Envelope {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]domain.Envelope(nil), p.published...)
+}
+
+// Reset forgets the recorded envelopes.
+func (p *Publisher) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.published = nil
+}
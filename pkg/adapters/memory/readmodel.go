@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"context"
+	"encoding/base64"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+)
+
+const defaultPageSize = 50
+
+// ReadModel is an in-memory stand-in for the DynamoDB read model. Items are
+// keyed the same way, by event, start time (second precision) and id, and
+// versions are tracked per reservation like the DynamoDB version items.
+type ReadModel struct {
+	mu    sync.Mutex
+	items map[string]domain.Reservation
+	// applied holds the last version written for each reservation id, with
+	// the event and start time its item was written under
+	applied map[string]domain.Reservation
+}
+
+func NewReadModel() *ReadModel {
+	return &ReadModel{items: map[string]domain.Reservation{}, applied: map[string]domain.Reservation{}}
+}
+
+func (r *ReadModel) SaveReadModel(ctx context.Context, res *domain.Reservation) error {
+	r.apply(res, time.Time{})
+	return nil
+}
+
+func (r *ReadModel) MoveReadModel(ctx context.Context, res *domain.Reservation, oldStartTime time.Time) error {
+	r.apply(res, oldStartTime)
+	return nil
+}
+
+func (r *ReadModel) apply(res *domain.Reservation, previousStart time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.applied[res.ID]
+	if ok && stored.Version >= res.Version {
+		return
+	}
+	if ok {
+		delete(r.items, readModelKey(stored.EventID, stored.StartTime, res.ID))
+	}
+	if !previousStart.IsZero() {
+		delete(r.items, readModelKey(res.EventID, previousStart, res.ID))
+	}
+	r.items[readModelKey(res.EventID, res.StartTime, res.ID)] = *res
+	r.applied[res.ID] = domain.Reservation{EventID: res.EventID, StartTime: res.StartTime, Version: res.Version}
+}
+
+// Apply projects an envelope the way the worker does.
+func (r *ReadModel) Apply(event domain.Envelope) error {
+	decoded, err := event.DecodeEvent()
+	if err != nil {
+		return err
+	}
+
+	var snapshot domain.ReservationSnapshot
+	var previousStart time.Time
+	switch e := decoded.(type) {
+	case *domain.ReservationCreated:
+		snapshot = e.ReservationSnapshot
+	case *domain.ReservationCancelled:
+		snapshot = e.ReservationSnapshot
+	case *domain.ReservationCompleted:
+		snapshot = e.ReservationSnapshot
+	case *domain.ReservationModified:
+		snapshot, previousStart = e.ReservationSnapshot, e.PreviousStartTime
+	case *domain.ReservationConfirmed:
+		snapshot = e.ReservationSnapshot
+	case *domain.ReservationHoldExpired:
+		snapshot = e.ReservationSnapshot
+	case *domain.ReservationCheckedIn:
+		snapshot = e.ReservationSnapshot
+	case *domain.ReservationNoShow:
+		snapshot = e.ReservationSnapshot
+	default:
+		return nil
+	}
+
+	r.apply(&domain.Reservation{
+		ID:          snapshot.ReservationID,
+		UserID:      snapshot.UserID,
+		EventID:     snapshot.EventID,
+		StartTime:   snapshot.StartTime,
+		EndTime:     snapshot.EndTime,
+		TicketCount: snapshot.TicketCount,
+		Status:      snapshot.Status,
+		UpdatedAt:   time.Now(),
+		ExpiresAt:   snapshot.ExpiresAt,
+		Version:     event.Version,
+	}, previousStart)
+	return nil
+}
+
+// ListByEvent mirrors the DynamoDB query: reservations of an event starting
+// in [start, end), cancelled and expired ones skipped, ordered by start
+// time then id.
+func (r *ReadModel) ListByEvent(ctx context.Context, eventID string, start, end time.Time, limit int, pageToken string) (*ports.ReservationPage, error) {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+
+	after := ""
+	if pageToken != "" {
+		b, err := base64.RawURLEncoding.DecodeString(pageToken)
+		if err != nil {
+			return nil, ports.ErrInvalidPageToken
+		}
+		after = string(b)
+	}
+
+	from := readModelKey(eventID, start, "")
+	to := readModelKey(eventID, end, "")
+
+	r.mu.Lock()
+	var keys []string
+	for key, item := range r.items {
+		if key < from || key >= to || key <= after || item.EventID != eventID || item.Status.Released() {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	page := &ports.ReservationPage{Items: []*domain.Reservation{}}
+	for _, key := range keys {
+		if len(page.Items) == limit {
+			last := readModelKey(eventID, page.Items[len(page.Items)-1].StartTime, page.Items[len(page.Items)-1].ID)
+			page.NextPageToken = base64.RawURLEncoding.EncodeToString([]byte(last))
+			break
+		}
+		item := r.items[key]
+		page.Items = append(page.Items, &item)
+	}
+	r.mu.Unlock()
+	return page, nil
+}
+
+func readModelKey(eventID string, startTime time.Time, reservationID string) string {
+	return eventID + "#" + startTime.UTC().Format(time.RFC3339) + "#" + reservationID
+}
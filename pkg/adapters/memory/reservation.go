@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+)
+
+type ReservationRepository struct {
+	store *Store
+}
+
+func NewReservationRepository(store *Store) *ReservationRepository {
+	return &ReservationRepository{store: store}
+}
+
+func (r *ReservationRepository) Save(ctx context.Context, res *domain.Reservation, events ...ports.OutboxMessage) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.reservations[res.ID]; ok {
+		return ErrDuplicateID
+	}
+	if err := s.reserveCapacity(res); err != nil {
+		return err
+	}
+
+	stored := *res
+	stored.ExpiresAt = cloneTime(res.ExpiresAt)
+	stored.ClearPendingTransitions()
+	s.reservations[res.ID] = stored
+	s.appendTransitions(res)
+	s.appendOutbox(events)
+	return nil
+}
+
+func (r *ReservationRepository) Update(ctx context.Context, res *domain.Reservation, events ...ports.OutboxMessage) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Either the reservation is gone or someone else bumped the version first
+	current, ok := s.reservations[res.ID]
+	if !ok || current.Version != res.Version {
+		return domain.ErrVersionConflict
+	}
+
+	if res.NeedsCapacityCheck(&current) {
+		if err := s.reserveCapacity(res); err != nil {
+			return err
+		}
+	}
+
+	res.Version++
+	current.StartTime = res.StartTime
+	current.EndTime = res.EndTime
+	current.TicketCount = res.TicketCount
+	current.Status = res.Status
+	current.UpdatedAt = res.UpdatedAt
+	current.ExpiresAt = cloneTime(res.ExpiresAt)
+	current.Version = res.Version
+	s.reservations[res.ID] = current
+
+	s.appendTransitions(res)
+	s.appendOutbox(events)
+	return nil
+}
+
+// appendTransitions stores and clears the pending transitions of res. The
+// caller must hold the lock.
+func (s *Store) appendTransitions(res *domain.Reservation) {
+	s.transitions[res.ID] = append(s.transitions[res.ID], res.PendingTransitions()...)
+	res.ClearPendingTransitions()
+}
+
+func (r *ReservationRepository) ListTransitions(ctx context.Context, reservationID string) ([]domain.Transition, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]domain.Transition(nil), s.transitions[reservationID]...), nil
+}
+
+func (r *ReservationRepository) GetByID(ctx context.Context, id string) (*domain.Reservation, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, ok := s.reservations[id]
+	if !ok {
+		return nil, nil
+	}
+	return &res, nil
+}
+
+func (r *ReservationRepository) GetByEventAndRange(ctx context.Context, eventID string, start, end time.Time) ([]*domain.Reservation, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var reservations []*domain.Reservation
+	for _, res := range s.reservations {
+		if res.EventID != eventID || res.Status.Released() {
+			continue
+		}
+		if res.StartTime.Before(start) || !res.StartTime.Before(end) {
+			continue
+		}
+		res := res
+		reservations = append(reservations, &res)
+	}
+	sort.Slice(reservations, func(i, j int) bool { return reservations[i].StartTime.Before(reservations[j].StartTime) })
+	return reservations, nil
+}
+
+func (r *ReservationRepository) ListExpiredHolds(ctx context.Context, now time.Time, limit int) ([]*domain.Reservation, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var reservations []*domain.Reservation
+	for _, res := range s.reservations {
+		if res.Status != domain.StatusHeld || !res.HoldExpired(now) {
+			continue
+		}
+		res := res
+		reservations = append(reservations, &res)
+	}
+	sort.Slice(reservations, func(i, j int) bool { return reservations[i].ExpiresAt.Before(*reservations[j].ExpiresAt) })
+	if len(reservations) > limit {
+		reservations = reservations[:limit]
+	}
+	return reservations, nil
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+// reserveCapacity mirrors the Postgres check: zero limits are unlimited,
+// and neither the reservation's own previous tickets nor holds lapsed by
+// res.UpdatedAt are counted. The caller must hold the lock.
+func (s *Store) reserveCapacity(res *domain.Reservation) error {
+	if res.Status.Released() {
+		return nil
+	}
+
+	event, ok := s.events[res.EventID]
+	if !ok {
+		return domain.ErrEventNotFound
+	}
+	if event.Capacity == 0 && event.SlotCapacity == 0 {
+		return nil
+	}
+
+	now := res.UpdatedAt
+	var booked, slotBooked int
+	for _, other := range s.reservations {
+		if other.EventID != res.EventID || other.ID == res.ID || other.Status.Released() || other.HoldExpired(now) {
+			continue
+		}
+		booked += other.TicketCount
+		if other.StartTime.Equal(res.StartTime) {
+			slotBooked += other.TicketCount
+		}
+	}
+
+	if event.Capacity > 0 && booked+res.TicketCount > event.Capacity {
+		return domain.ErrCapacityExceeded
+	}
+	if event.SlotCapacity > 0 && slotBooked+res.TicketCount > event.SlotCapacity {
+		return domain.ErrCapacityExceeded
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Package memory implements the core ports in process memory. It backs
+// the API's -storage=memory mode and tests; nothing survives a restart.
+package memory
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+)
+
+var ErrDuplicateID = errors.New("memory: duplicate id")
+
+// Store holds the state shared by the repositories. A single lock covers
+// all of it, which gives Save and Update the same atomicity as a Postgres
+// transaction: the capacity check, the write and the outbox insert happen
+// together.
+type Store struct {
+	mu sync.Mutex
+
+	reservations map[string]domain.Reservation
+	transitions  map[string][]domain.Transition
+	events       map[string]domain.Event
+	outbox       []outboxEntry
+	nextOutboxID int64
+	idempotency  map[string]ports.IdempotencyRecord
+}
+
+type outboxEntry struct {
+	msg         ports.OutboxMessage
+	lockedUntil time.Time
+	parkedAt    time.Time
+	lastError   string
+}
+
+func NewStore() *Store {
+	return &Store{
+		reservations: map[string]domain.Reservation{},
+		transitions:  map[string][]domain.Transition{},
+		events:       map[string]domain.Event{},
+		idempotency:  map[string]ports.IdempotencyRecord{},
+	}
+}
+
+// SeedCatalog adds the events that migration 002 seeds in Postgres, so a
+// fresh in-memory API has something to book.
+func (s *Store) SeedCatalog() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, e := range []struct{ id, name, venue string }{
+		{"event-1", "Late Night Comedy", "The Basement Club"},
+		{"event-2", "Jazz Quartet", "Blue Note Lounge"},
+		{"event-3", "Indie Film Festival", "Cinema 4"},
+		{"event-4", "Tech Conference 2026", "Convention Center"},
+		{"event-5", "Live Podcast Recording", "Studio A"},
+		{"event-6", "Charity Gala", "Grand Ballroom"},
+	} {
+		if _, ok := s.events[e.id]; ok {
+			continue
+		}
+		s.events[e.id] = domain.Event{
+			ID:        e.id,
+			Name:      e.name,
+			Venue:     e.venue,
+			Timezone:  "UTC",
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Format selects how events are encoded on the wire.
+type Format string
+
+const (
+	// FormatEnvelope publishes the domain.Envelope as plain JSON.
+	FormatEnvelope Format = "envelope"
+	// FormatCloudEventsStructured publishes a CloudEvents 1.0 JSON document.
+	FormatCloudEventsStructured Format = "cloudevents-structured"
+	// FormatCloudEventsBinary publishes the event data as the body and the
+	// CloudEvents attributes as ce-* headers.
+	FormatCloudEventsBinary Format = "cloudevents-binary"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatEnvelope, FormatCloudEventsStructured, FormatCloudEventsBinary:
+		return f, nil
+	case "":
+		return FormatEnvelope, nil
+	}
+	return "", fmt.Errorf("unknown event format %q", s)
+}
+
+const (
+	cloudEventsSpecVersion  = "1.0"
+	cloudEventsContentType  = "application/cloudevents+json"
+	cloudEventsHeaderPrefix = "ce-"
+
+	// DefaultCloudEventsSource identifies this service as the event producer.
+	DefaultCloudEventsSource = "/booking-appointment/reservations"
+
+	// cloudEventsTypePrefix namespaces types per the spec's reverse-DNS
+	// recommendation: ReservationCreated becomes
+	// com.booking-appointment.reservation.created.
+	cloudEventsTypePrefix = "com.booking-appointment."
+)
+
+// cloudEvent is the structured-mode JSON representation. Extension
+// attributes must be lowercase alphanumeric.
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+
+	CorrelationID    string `json:"correlationid,omitempty"`
+	AggregateVersion int    `json:"aggregateversion"`
+	SchemaVersion    int    `json:"schemaversion"`
+}
+
+// encodeMessage renders an envelope in the given format.
+func encodeMessage(format Format, source string, event domain.Envelope) (amqp.Publishing, error) {
+	msg := amqp.Publishing{
+		DeliveryMode:  amqp.Persistent,
+		MessageId:     event.ID,
+		Type:          event.Type,
+		CorrelationId: event.CorrelationID,
+		Timestamp:     event.OccurredAt,
+	}
+
+	switch format {
+	case FormatCloudEventsStructured:
+		body, err := json.Marshal(cloudEvent{
+			SpecVersion:      cloudEventsSpecVersion,
+			ID:               event.ID,
+			Source:           source,
+			Type:             cloudEventsType(event.Type),
+			Subject:          event.AggregateID,
+			Time:             event.OccurredAt,
+			DataContentType:  "application/json",
+			Data:             event.Data,
+			CorrelationID:    event.CorrelationID,
+			AggregateVersion: event.Version,
+			SchemaVersion:    event.SchemaVersion,
+		})
+		if err != nil {
+			return amqp.Publishing{}, err
+		}
+		msg.ContentType = cloudEventsContentType
+		msg.Body = body
+
+	case FormatCloudEventsBinary:
+		msg.ContentType = "application/json"
+		msg.Headers = amqp.Table{
+			"ce-specversion":      cloudEventsSpecVersion,
+			"ce-id":               event.ID,
+			"ce-source":           source,
+			"ce-type":             cloudEventsType(event.Type),
+			"ce-subject":          event.AggregateID,
+			"ce-time":             event.OccurredAt.UTC().Format(time.RFC3339Nano),
+			"ce-aggregateversion": int64(event.Version),
+			"ce-schemaversion":    int64(event.SchemaVersion),
+		}
+		if event.CorrelationID != "" {
+			msg.Headers["ce-correlationid"] = event.CorrelationID
+		}
+		msg.Body = event.Data
+
+	default:
+		body, err := json.Marshal(event)
+		if err != nil {
+			return amqp.Publishing{}, err
+		}
+		msg.ContentType = "application/json"
+		msg.Body = body
+	}
+	return msg, nil
+}
+
+// decodeMessage accepts any format encodeMessage produces. Messages that
+// are neither kind of CloudEvent are returned as a plain envelope, which
+// may be empty for legacy payloads.
+func decodeMessage(contentType string, headers amqp.Table, body []byte) (domain.Envelope, error) {
+	if _, ok := headers["ce-specversion"]; ok {
+		return decodeBinaryCloudEvent(headers, body)
+	}
+
+	if strings.HasPrefix(contentType, cloudEventsContentType) {
+		var ce cloudEvent
+		if err := json.Unmarshal(body, &ce); err != nil {
+			return domain.Envelope{}, err
+		}
+		if ce.SpecVersion != cloudEventsSpecVersion {
+			return domain.Envelope{}, fmt.Errorf("unsupported CloudEvents specversion %q", ce.SpecVersion)
+		}
+		return domain.Envelope{
+			ID:            ce.ID,
+			Type:          eventTypeFromCloudEvents(ce.Type),
+			AggregateID:   ce.Subject,
+			SchemaVersion: ce.SchemaVersion,
+			Version:       ce.AggregateVersion,
+			OccurredAt:    ce.Time,
+			CorrelationID: ce.CorrelationID,
+			Data:          ce.Data,
+		}, nil
+	}
+
+	var envelope domain.Envelope
+	err := json.Unmarshal(body, &envelope)
+	return envelope, err
+}
+
+func decodeBinaryCloudEvent(headers amqp.Table, body []byte) (domain.Envelope, error) {
+	header := func(name string) string {
+		v, _ := headers[cloudEventsHeaderPrefix+name].(string)
+		return v
+	}
+
+	if v := header("specversion"); v != cloudEventsSpecVersion {
+		return domain.Envelope{}, fmt.Errorf("unsupported CloudEvents specversion %q", v)
+	}
+
+	var occurredAt time.Time
+	if v := header("time"); v != "" {
+		var err error
+		if occurredAt, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			return domain.Envelope{}, fmt.Errorf("ce-time: %v", err)
+		}
+	}
+
+	return domain.Envelope{
+		ID:            header("id"),
+		Type:          eventTypeFromCloudEvents(header("type")),
+		SchemaVersion: headerInt(headers[cloudEventsHeaderPrefix+"schemaversion"]),
+		AggregateID:   header("subject"),
+		Version:       headerInt(headers[cloudEventsHeaderPrefix+"aggregateversion"]),
+		OccurredAt:    occurredAt,
+		CorrelationID: header("correlationid"),
+		Data:          body,
+	}, nil
+}
+
+func cloudEventsType(eventType string) string {
+	return cloudEventsTypePrefix + RoutingKey(eventType)
+}
+
+// eventTypeFromCloudEvents reverses cloudEventsType:
+// com.booking-appointment.reservation.created becomes ReservationCreated.
+// Types from other producers are returned unchanged.
+func eventTypeFromCloudEvents(ceType string) string {
+	key, ok := strings.CutPrefix(ceType, cloudEventsTypePrefix)
+	if !ok {
+		return ceType
+	}
+
+	var b strings.Builder
+	for _, word := range strings.Split(key, ".") {
+		for i, r := range word {
+			if i == 0 {
+				r = unicode.ToUpper(r)
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
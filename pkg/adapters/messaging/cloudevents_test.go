@@ -0,0 +1,111 @@
+package messaging
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	envelope := domain.Envelope{
+		ID:            "evt-1",
+		Type:          domain.EventReservationHoldExpired,
+		SchemaVersion: domain.EventSchemaVersion,
+		AggregateID:   "res-1",
+		Version:       3,
+		OccurredAt:    time.Date(2030, 1, 10, 12, 0, 0, 123456789, time.UTC),
+		CorrelationID: "corr-1",
+		Data:          []byte(`{"reservation_id":"res-1","status":"EXPIRED"}`),
+	}
+	withoutCorrelation := envelope
+	withoutCorrelation.CorrelationID = ""
+
+	tests := []struct {
+		format          Format
+		envelope        domain.Envelope
+		wantContentType string
+	}{
+		{FormatEnvelope, envelope, "application/json"},
+		{FormatCloudEventsStructured, envelope, cloudEventsContentType},
+		{FormatCloudEventsStructured, withoutCorrelation, cloudEventsContentType},
+		{FormatCloudEventsBinary, envelope, "application/json"},
+		{FormatCloudEventsBinary, withoutCorrelation, "application/json"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			msg, err := encodeMessage(tt.format, DefaultCloudEventsSource, tt.envelope)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if msg.ContentType != tt.wantContentType {
+				t.Errorf("content type = %q; want %q", msg.ContentType, tt.wantContentType)
+			}
+			if msg.MessageId != tt.envelope.ID || msg.Type != tt.envelope.Type {
+				t.Errorf("message id, type = %q, %q; want %q, %q", msg.MessageId, msg.Type, tt.envelope.ID, tt.envelope.Type)
+			}
+
+			got, err := decodeMessage(msg.ContentType, msg.Headers, msg.Body)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			assertEnvelope(t, got, tt.envelope)
+		})
+	}
+}
+
+func TestDecodeRejectsUnknownSpecVersion(t *testing.T) {
+	msg, err := encodeMessage(FormatCloudEventsBinary, DefaultCloudEventsSource, domain.Envelope{ID: "evt-1", Type: domain.EventReservationCreated})
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	msg.Headers["ce-specversion"] = "0.3"
+	if _, err := decodeMessage(msg.ContentType, msg.Headers, msg.Body); err == nil {
+		t.Error("binary event with specversion 0.3 decoded; want an error")
+	}
+
+	body := []byte(`{"specversion":"0.3","id":"evt-1","type":"com.booking-appointment.reservation.created"}`)
+	if _, err := decodeMessage(cloudEventsContentType, nil, body); err == nil {
+		t.Error("structured event with specversion 0.3 decoded; want an error")
+	}
+}
+
+func TestCloudEventsTypeRoundTrip(t *testing.T) {
+	tests := []struct {
+		eventType string
+		ceType    string
+	}{
+		{domain.EventReservationCreated, "com.booking-appointment.reservation.created"},
+		{domain.EventReservationHoldExpired, "com.booking-appointment.reservation.hold.expired"},
+		{domain.EventReservationCheckedIn, "com.booking-appointment.reservation.checked.in"},
+		{domain.EventReservationNoShow, "com.booking-appointment.reservation.no.show"},
+	}
+	for _, tt := range tests {
+		if got := cloudEventsType(tt.eventType); got != tt.ceType {
+			t.Errorf("cloudEventsType(%q) = %q; want %q", tt.eventType, got, tt.ceType)
+		}
+		if got := eventTypeFromCloudEvents(tt.ceType); got != tt.eventType {
+			t.Errorf("eventTypeFromCloudEvents(%q) = %q; want %q", tt.ceType, got, tt.eventType)
+		}
+	}
+
+	// Types from other producers pass through untouched
+	if got := eventTypeFromCloudEvents("org.example.order.placed"); got != "org.example.order.placed" {
+		t.Errorf("foreign type became %q", got)
+	}
+}
+
+func assertEnvelope(t *testing.T, got, want domain.Envelope) {
+	t.Helper()
+	if got.ID != want.ID || got.Type != want.Type || got.SchemaVersion != want.SchemaVersion ||
+		got.AggregateID != want.AggregateID || got.Version != want.Version || got.CorrelationID != want.CorrelationID {
+		t.Errorf("envelope = %+v; want %+v", got, want)
+	}
+	if !got.OccurredAt.Equal(want.OccurredAt) {
+		t.Errorf("occurred at %s; want %s", got.OccurredAt, want.OccurredAt)
+	}
+	if !bytes.Equal(got.Data, want.Data) {
+		t.Errorf("data = %s; want %s", got.Data, want.Data)
+	}
+}
@@ -0,0 +1,141 @@
+package messaging
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Connection wraps an AMQP connection and redials it with exponential
+// backoff whenever the broker drops it. Channels do not survive a
+// reconnect; users open a new one with Channel and re-declare topology.
+type Connection struct {
+	url string
+
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	mu   sync.RWMutex
+	conn *amqp.Connection
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Dial connects to the broker. The first attempt must succeed so that a
+// misconfigured URL fails fast at startup instead of retrying forever.
+func Dial(url string) (*Connection, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Connection{
+		url:        url,
+		MinBackoff: time.Second,
+		MaxBackoff: 30 * time.Second,
+		conn:       conn,
+		done:       make(chan struct{}),
+	}
+	go c.watch(conn)
+	return c, nil
+}
+
+// Channel opens a channel on the current connection, waiting for a
+// reconnect in progress to finish.
+func (c *Connection) Channel(ctx context.Context) (*amqp.Channel, error) {
+	for {
+		c.mu.RLock()
+		conn := c.conn
+		c.mu.RUnlock()
+
+		if !conn.IsClosed() {
+			ch, err := conn.Channel()
+			if err == nil {
+				return ch, nil
+			}
+			if err != amqp.ErrClosed {
+				return nil, err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.done:
+			return nil, amqp.ErrClosed
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// IsClosed reports whether the broker is currently unreachable.
+func (c *Connection) IsClosed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conn.IsClosed()
+}
+
+// Close stops reconnecting and closes the underlying connection.
+func (c *Connection) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conn.Close()
+}
+
+func (c *Connection) watch(conn *amqp.Connection) {
+	for {
+		reason := <-conn.NotifyClose(make(chan *amqp.Error, 1))
+
+		select {
+		case <-c.done:
+			return
+		default:
+		}
+		log.Printf("RabbitMQ connection lost: %v; reconnecting", reason)
+
+		conn = c.redial()
+		if conn == nil {
+			return
+		}
+		c.mu.Lock()
+		select {
+		case <-c.done:
+			// Close raced with the redial; don't leak the new connection
+			c.mu.Unlock()
+			conn.Close()
+			return
+		default:
+		}
+		c.conn = conn
+		c.mu.Unlock()
+		log.Println("RabbitMQ connection re-established")
+	}
+}
+
+// redial retries until it connects or Close is called, in which case it
+// returns nil.
+func (c *Connection) redial() *amqp.Connection {
+	delay := c.MinBackoff
+	for {
+		select {
+		case <-c.done:
+			return nil
+		case <-time.After(delay):
+		}
+
+		conn, err := amqp.Dial(c.url)
+		if err == nil {
+			return conn
+		}
+		if delay *= 2; delay > c.MaxBackoff {
+			delay = c.MaxBackoff
+		}
+		log.Printf("RabbitMQ reconnect failed, retrying in %s: %v", delay, err)
+	}
+}
@@ -0,0 +1,134 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// DeadLetterQueue gives operators access to messages the worker gave up on.
+type DeadLetterQueue struct {
+	conn *Connection
+}
+
+func NewDeadLetterQueue(conn *Connection) *DeadLetterQueue {
+	return &DeadLetterQueue{conn: conn}
+}
+
+type DeadLetter struct {
+	MessageID string `json:"message_id,omitempty"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+	FailedAt  string `json:"failed_at,omitempty"`
+	Body      string `json:"body"`
+}
+
+// Inspect returns up to limit dead letters without removing them.
+func (q *DeadLetterQueue) Inspect(ctx context.Context, limit int) ([]DeadLetter, error) {
+	ch, err := q.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+
+	var letters []DeadLetter
+	var last uint64
+	for len(letters) < limit {
+		d, ok, err := ch.Get(DeadLetterQueueName, false)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		last = d.DeliveryTag
+
+		lastError, _ := d.Headers[headerLastError].(string)
+		failedAt, _ := d.Headers[headerFailedAt].(string)
+		letters = append(letters, DeadLetter{
+			MessageID: d.MessageId,
+			Attempts:  retryCount(d.Headers),
+			LastError: lastError,
+			FailedAt:  failedAt,
+			Body:      string(d.Body),
+		})
+	}
+
+	// Put everything back in its original position
+	if last > 0 {
+		if err := ch.Nack(last, true, true); err != nil {
+			return nil, err
+		}
+	}
+	return letters, nil
+}
+
+// Replay moves up to limit dead letters back onto the main queue with a
+// fresh retry budget and returns how many were moved.
+func (q *DeadLetterQueue) Replay(ctx context.Context, limit int) (int, error) {
+	ch, err := q.open(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	// Confirms make sure a message is only removed once the broker has the copy
+	if err := ch.Confirm(false); err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for replayed < limit {
+		d, ok, err := ch.Get(DeadLetterQueueName, false)
+		if err != nil {
+			return replayed, err
+		}
+		if !ok {
+			break
+		}
+
+		headers := copyHeaders(d.Headers)
+		delete(headers, headerRetryCount)
+		delete(headers, headerLastError)
+		delete(headers, headerFailedAt)
+
+		conf, err := ch.PublishWithDeferredConfirmWithContext(ctx, "", QueueName, false, false, amqp.Publishing{
+			ContentType:  d.ContentType,
+			MessageId:    d.MessageId,
+			DeliveryMode: amqp.Persistent,
+			Headers:      headers,
+			Body:         d.Body,
+		})
+		if err != nil {
+			d.Nack(false, true)
+			return replayed, err
+		}
+		acked, err := conf.WaitContext(ctx)
+		if err != nil || !acked {
+			d.Nack(false, true)
+			if err == nil {
+				err = fmt.Errorf("broker rejected replayed message %d", d.DeliveryTag)
+			}
+			return replayed, err
+		}
+
+		if err := d.Ack(false); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+func (q *DeadLetterQueue) open(ctx context.Context) (*amqp.Channel, error) {
+	ch, err := q.conn.Channel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := declareTopology(ch); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	return ch, nil
+}
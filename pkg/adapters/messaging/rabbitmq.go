@@ -2,66 +2,141 @@ package messaging
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
 
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+var errPublishNacked = errors.New("broker rejected message")
+
+// RabbitMQPublisher publishes with publisher confirms: Publish returns nil
+// only once the broker has acked the message. A lost channel is reopened
+// on the next Publish, so the outbox relay simply retries after an outage.
 type RabbitMQPublisher struct {
-	conn *amqp.Connection
-	ch   *amqp.Channel
+	conn *Connection
+
+	// Format selects the wire encoding; Source is the CloudEvents source
+	// attribute used by the CloudEvents formats.
+	Format Format
+	Source string
+
+	// Schemas rejects events that don't match their checked-in schema
+	// before they reach other teams' consumers.
+	Schemas *SchemaRegistry
+
+	// mu serialises publishes so each confirm matches its message
+	mu sync.Mutex
+	ch *amqp.Channel
 }
 
-func NewRabbitMQPublisher(conn *amqp.Connection) (*RabbitMQPublisher, error) {
-	ch, err := conn.Channel()
+func NewRabbitMQPublisher(conn *Connection) (*RabbitMQPublisher, error) {
+	registry, err := NewSchemaRegistry()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("load event schemas: %w", err)
 	}
 
-	// Ensure exchange exists
-	err = ch.ExchangeDeclare(
-		"events_exchange", // name
-		"topic",           // type
-		true,              // durable
-		false,             // auto-deleted
-		false,             // internal
-		false,             // no-wait
-		nil,               // arguments
-	)
-	if err != nil {
-		return nil, err
+	p := &RabbitMQPublisher{
+		conn:    conn,
+		Format:  FormatEnvelope,
+		Source:  DefaultCloudEventsSource,
+		Schemas: registry,
 	}
 
-	return &RabbitMQPublisher{conn: conn, ch: ch}, nil
+	// Open eagerly so a broken topology fails at startup
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.channel(context.Background()); err != nil {
+		return nil, err
+	}
+	return p, nil
 }
 
-func (p *RabbitMQPublisher) Publish(ctx context.Context, event interface{}) error {
+func (p *RabbitMQPublisher) Publish(ctx context.Context, event domain.Envelope) error {
 	if p == nil {
 		return nil
 	}
-	body, err := json.Marshal(event)
+	if p.Schemas != nil {
+		if err := p.Schemas.Validate(event); err != nil {
+			return err
+		}
+	}
+
+	msg, err := encodeMessage(p.Format, p.Source, event)
 	if err != nil {
 		return err
 	}
 
-	// We could parse event type to set routing key, e.g., "reservation.created"
-	// For simplicity, hardcoding or using a generic key
-	routingKey := "reservation.event"
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ch, err := p.channel(ctx)
+	if err != nil {
+		return err
+	}
 
-	return p.ch.PublishWithContext(ctx,
-		"events_exchange",
-		routingKey,
+	conf, err := ch.PublishWithDeferredConfirmWithContext(ctx,
+		ExchangeName,
+		RoutingKey(event.Type),
 		false, // mandatory
 		false, // immediate
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		},
+		msg,
 	)
+	if err != nil {
+		p.reset()
+		return err
+	}
+
+	acked, err := conf.WaitContext(ctx)
+	if err != nil {
+		// The channel may have died with the confirm outstanding
+		p.reset()
+		return err
+	}
+	if !acked {
+		return errPublishNacked
+	}
+	return nil
 }
 
 func (p *RabbitMQPublisher) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reset()
+}
+
+// channel returns the open confirm-mode channel, opening a new one after
+// a reconnect. The caller must hold mu.
+func (p *RabbitMQPublisher) channel(ctx context.Context) (*amqp.Channel, error) {
+	if p.ch != nil && !p.ch.IsClosed() {
+		return p.ch, nil
+	}
+
+	ch, err := p.conn.Channel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Re-declare on every new channel: the broker may have restarted
+	// without the exchange if it was not persisted
+	if err := declareTopology(ch); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	p.ch = ch
+	return ch, nil
+}
+
+func (p *RabbitMQPublisher) reset() {
 	if p.ch != nil {
 		p.ch.Close()
+		p.ch = nil
 	}
 }
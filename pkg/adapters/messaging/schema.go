@@ -0,0 +1,89 @@
+package messaging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"regexp"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+	"github.com/femisowemimo/booking-appointment/backend/schemas"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// ErrSchemaViolation wraps ports.ErrUnpublishable, so the outbox relay
+// parks such events instead of retrying them.
+var ErrSchemaViolation = fmt.Errorf("%w: event does not match its schema", ports.ErrUnpublishable)
+
+// schemaBaseURL only anchors relative $refs between the embedded files;
+// nothing is fetched from it.
+const schemaBaseURL = "file:///schemas/"
+
+var eventSchemaFile = regexp.MustCompile(`^([A-Z][A-Za-z]+)\.v(\d+)\.json$`)
+
+// SchemaRegistry validates envelopes against the JSON Schemas checked in
+// under schemas/, keyed by event type and schema version.
+type SchemaRegistry struct {
+	schemas map[string]*jsonschema.Schema
+}
+
+func NewSchemaRegistry() (*SchemaRegistry, error) {
+	entries, err := fs.ReadDir(schemas.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat()
+	for _, entry := range entries {
+		f, err := schemas.FS.Open(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		doc, err := jsonschema.UnmarshalJSON(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		if err := compiler.AddResource(schemaBaseURL+entry.Name(), doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+	}
+
+	registry := &SchemaRegistry{schemas: map[string]*jsonschema.Schema{}}
+	for _, entry := range entries {
+		match := eventSchemaFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		schema, err := compiler.Compile(schemaBaseURL + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		registry.schemas[match[1]+".v"+match[2]] = schema
+	}
+	return registry, nil
+}
+
+// Validate checks event against the schema for its type and version.
+func (r *SchemaRegistry) Validate(event domain.Envelope) error {
+	schema, ok := r.schemas[fmt.Sprintf("%s.v%d", event.Type, event.SchemaVersion)]
+	if !ok {
+		return fmt.Errorf("%w: no schema for %s version %d", ErrSchemaViolation, event.Type, event.SchemaVersion)
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if err := schema.Validate(doc); err != nil {
+		return fmt.Errorf("%w: %s %s: %v", ErrSchemaViolation, event.Type, event.ID, err)
+	}
+	return nil
+}
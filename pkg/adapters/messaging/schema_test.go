@@ -0,0 +1,85 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+)
+
+func TestSchemaRegistryVersions(t *testing.T) {
+	registry, err := NewSchemaRegistry()
+	if err != nil {
+		t.Fatalf("NewSchemaRegistry: %v", err)
+	}
+
+	start := time.Date(2030, 1, 10, 18, 0, 0, 0, time.UTC)
+	expires := start.Add(-24 * time.Hour)
+	snapshot := func(status domain.ReservationStatus, expiresAt *time.Time) domain.ReservationSnapshot {
+		return domain.ReservationSnapshot{
+			ReservationID: "res-1",
+			EventID:       "event-1",
+			UserID:        "user-1",
+			StartTime:     start,
+			EndTime:       start.Add(time.Hour),
+			TicketCount:   2,
+			Status:        status,
+			ExpiresAt:     expiresAt,
+		}
+	}
+	envelope := func(event domain.DomainEvent, schemaVersion int) domain.Envelope {
+		e, err := domain.NewEnvelope(context.Background(), "evt-1", event, 1, start)
+		if err != nil {
+			t.Fatalf("NewEnvelope: %v", err)
+		}
+		e.SchemaVersion = schemaVersion
+		return e
+	}
+
+	booked := &domain.ReservationCreated{ReservationSnapshot: snapshot(domain.StatusBooked, nil)}
+	held := &domain.ReservationCreated{ReservationSnapshot: snapshot(domain.StatusHeld, &expires)}
+	confirmed := &domain.ReservationConfirmed{ReservationSnapshot: snapshot(domain.StatusBooked, nil)}
+	checkedIn := &domain.ReservationCheckedIn{ReservationSnapshot: snapshot(domain.StatusCheckedIn, nil)}
+	noShow := &domain.ReservationNoShow{ReservationSnapshot: snapshot(domain.StatusNoShow, nil)}
+
+	tests := []struct {
+		name    string
+		event   domain.Envelope
+		wantErr bool
+	}{
+		{"current booking", envelope(booked, domain.EventSchemaVersion), false},
+		{"current hold", envelope(held, domain.EventSchemaVersion), false},
+		{"current confirmation", envelope(confirmed, domain.EventSchemaVersion), false},
+		{"current check-in", envelope(checkedIn, domain.EventSchemaVersion), false},
+		{"current no-show", envelope(noShow, domain.EventSchemaVersion), false},
+		{"version 1 booking", envelope(booked, 1), false},
+		// Holds did not exist in version 1
+		{"version 1 hold", envelope(held, 1), true},
+		{"version 1 confirmation", envelope(confirmed, 1), true},
+		// Nor did check-in
+		{"version 1 check-in", envelope(checkedIn, 1), true},
+		{"version 1 no-show", envelope(noShow, 1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := registry.Validate(tt.event)
+			if tt.wantErr && !errors.Is(err, ErrSchemaViolation) {
+				t.Fatalf("Validate = %v; want %v", err, ErrSchemaViolation)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+		})
+	}
+
+	// A version 1 event still in flight upcasts to one the current schema accepts
+	upcast, err := domain.Upcast(envelope(booked, 1))
+	if err != nil {
+		t.Fatalf("Upcast: %v", err)
+	}
+	if err := registry.Validate(upcast); err != nil {
+		t.Fatalf("Validate after upcasting: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package messaging
+
+import (
+	"strings"
+	"unicode"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	ExchangeName = "events_exchange"
+	QueueName    = "reservation_updates"
+
+	// Failed messages wait in the retry queue until their per-message TTL
+	// expires, then RabbitMQ dead-letters them back onto QueueName.
+	RetryQueueName = "reservation_updates.retry"
+
+	// Messages that exhausted their retries are parked here for inspection.
+	DeadLetterExchangeName = "reservation_updates.dlx"
+	DeadLetterQueueName    = "reservation_updates.dlq"
+)
+
+// Headers used to track delivery attempts across the retry loop.
+const (
+	headerRetryCount = "x-retry-count"
+	headerLastError  = "x-last-error"
+	headerFailedAt   = "x-failed-at"
+)
+
+// declareTopology declares the exchanges and queues the worker relies on.
+// All declarations are idempotent.
+func declareTopology(ch *amqp.Channel) error {
+	if err := ch.ExchangeDeclare(ExchangeName, "topic", true, false, false, false, nil); err != nil {
+		return err
+	}
+
+	// Main queue arguments are left untouched: redeclaring an existing
+	// durable queue with different arguments fails.
+	if _, err := ch.QueueDeclare(QueueName, true, false, false, false, nil); err != nil {
+		return err
+	}
+	if err := ch.QueueBind(QueueName, "reservation.#", ExchangeName, false, nil); err != nil {
+		return err
+	}
+
+	_, err := ch.QueueDeclare(RetryQueueName, true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange":    "", // Default exchange routes by queue name
+		"x-dead-letter-routing-key": QueueName,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := ch.ExchangeDeclare(DeadLetterExchangeName, "fanout", true, false, false, false, nil); err != nil {
+		return err
+	}
+	if _, err := ch.QueueDeclare(DeadLetterQueueName, true, false, false, false, nil); err != nil {
+		return err
+	}
+	return ch.QueueBind(DeadLetterQueueName, "", DeadLetterExchangeName, false, nil)
+}
+
+// RoutingKey derives the topic routing key from an event type by splitting
+// it into words: ReservationCreated becomes reservation.created.
+func RoutingKey(eventType string) string {
+	var b strings.Builder
+	for i, r := range eventType {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('.')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// retryCount reads the attempt counter.
+func retryCount(headers amqp.Table) int {
+	return headerInt(headers[headerRetryCount])
+}
+
+// headerInt reads an integer header; AMQP integer widths vary by client.
+func headerInt(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	}
+	return 0
+}
+
+func copyHeaders(headers amqp.Table) amqp.Table {
+	out := amqp.Table{}
+	for k, v := range headers {
+		out[k] = v
+	}
+	return out
+}
@@ -2,68 +2,114 @@ package messaging
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"strconv"
+	"time"
 
-	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/repositories"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+// errMalformedMessage marks failures that retrying cannot fix.
+var errMalformedMessage = errors.New("malformed message")
+
 type Worker struct {
-	conn       *amqp.Connection
-	dynamoRepo *repositories.DynamoDBReservationRepository
+	conn      *Connection
+	readModel ports.ReservationReadModelWriter
+	clock     ports.Clock
+
+	// MaxRetries is how many times a failing message is retried before it
+	// is dead-lettered. Backoff doubles from BaseBackoff up to MaxBackoff.
+	MaxRetries  int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	// Prefetch limits unacknowledged deliveries held by the worker.
+	Prefetch int
 }
 
-func NewWorker(conn *amqp.Connection, dynamoRepo *repositories.DynamoDBReservationRepository) *Worker {
+func NewWorker(conn *Connection, readModel ports.ReservationReadModelWriter, clock ports.Clock) *Worker {
 	return &Worker{
-		conn:       conn,
-		dynamoRepo: dynamoRepo,
+		conn:        conn,
+		readModel:   readModel,
+		clock:       clock,
+		MaxRetries:  5,
+		BaseBackoff: time.Second,
+		MaxBackoff:  5 * time.Minute,
+		Prefetch:    10,
 	}
 }
 
-func (w *Worker) Start() error {
-	ch, err := w.conn.Channel()
+// Start consumes until ctx is cancelled. When the channel or connection
+// is lost it resubscribes with backoff once the connection is back;
+// unacked deliveries are redelivered by the broker. On cancellation it
+// stops the consumer and finishes the deliveries already received.
+func (w *Worker) Start(ctx context.Context) error {
+	delay := w.conn.MinBackoff
+	for {
+		subscribed, err := w.consume(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		// Only back off further while resubscribing keeps failing
+		if subscribed {
+			delay = w.conn.MinBackoff
+		}
+		log.Printf("Consumer stopped: %v; resubscribing in %s", err, delay)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > w.conn.MaxBackoff {
+			delay = w.conn.MaxBackoff
+		}
+	}
+}
+
+// consume runs a single subscription. It returns nil after a clean
+// shutdown and an error when the subscription was lost or could not be
+// set up; subscribed reports whether consuming had started.
+func (w *Worker) consume(ctx context.Context) (subscribed bool, err error) {
+	ch, err := w.conn.Channel(ctx)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer ch.Close()
 
-	// Ensure Queue Exists
-	q, err := ch.QueueDeclare(
-		"reservation_updates", // name
-		true,                  // durable
-		false,                 // delete when unused
-		false,                 // exclusive
-		false,                 // no-wait
-		nil,                   // arguments
-	)
-	if err != nil {
-		return err
+	// Ensure queues, retry queue and dead-letter queue exist
+	if err := declareTopology(ch); err != nil {
+		return false, err
 	}
 
-	// Bind Queue to Exchange
-	err = ch.QueueBind(
-		q.Name,
-		"reservation.#",   // routing key (was appointment.#)
-		"events_exchange", // exchange
-		false,
-		nil,
-	)
-	if err != nil {
-		return err
+	// A failed delivery is only acked once the broker confirms its retry
+	// or dead-letter copy
+	if err := ch.Confirm(false); err != nil {
+		return false, err
 	}
 
+	// Bound how much is buffered client-side and must be drained on shutdown
+	if err := ch.Qos(w.Prefetch, 0, false); err != nil {
+		return false, err
+	}
+
+	consumerTag := "reservation-worker-" + uuid.New().String()
 	msgs, err := ch.Consume(
-		q.Name, // queue
-		"",     // consumer tag
-		false,  // auto-ack (We want manual ack for reliability)
-		false,  // exclusive
-		false,  // no-local
-		false,  // no-wait
-		nil,    // args
+		QueueName,   // queue
+		consumerTag, // consumer tag
+		false,       // auto-ack (We want manual ack for reliability)
+		false,       // exclusive
+		false,       // no-local
+		false,       // no-wait
+		nil,         // args
 	)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	done := make(chan struct{})
@@ -73,47 +119,178 @@ func (w *Worker) Start() error {
 		for d := range msgs {
 			log.Printf("Received a message: %s", d.Body)
 
-			if err := w.processMessage(d.Body); err != nil {
+			if err := w.processMessage(d); err != nil {
 				log.Printf("Error processing message: %v", err)
-				// Basic retry strategy: Nack with requeue (dangerous loop if permanent fail)
-				// For prod, use DLQ or retry count.
-				d.Nack(false, true)
-			} else {
-				d.Ack(false)
+				if err := w.retryOrDeadLetter(ch, d, err); err != nil {
+					// Could not reroute it; requeue rather than lose it
+					log.Printf("Failed to schedule retry: %v", err)
+					d.Nack(false, true)
+					continue
+				}
 			}
+			d.Ack(false)
 		}
 		log.Println("Consumer loop exited")
 	}()
 
 	log.Printf(" [*] Waiting for messages. To exit press CTRL+C")
+
+	select {
+	case <-done:
+		// The channel or connection closed underneath us
+		return true, errors.New("consumer channel closed")
+	case <-ctx.Done():
+	}
+
+	// Cancelling closes msgs once the broker confirms, after which the loop
+	// drains whatever was already delivered
+	log.Println("Stopping consumer, draining in-flight messages...")
+	if err := ch.Cancel(consumerTag, false); err != nil {
+		return true, err
+	}
 	<-done
 
+	return true, nil
+}
+
+// retryOrDeadLetter republishes a failed delivery to the retry queue with an
+// exponential delay, or to the dead-letter exchange once MaxRetries is
+// reached, and waits for the broker to confirm the copy. ch must be in
+// confirm mode. The caller acks the original delivery on success.
+func (w *Worker) retryOrDeadLetter(ch *amqp.Channel, d amqp.Delivery, cause error) error {
+	attempts := retryCount(d.Headers)
+	headers := copyHeaders(d.Headers)
+	headers[headerLastError] = cause.Error()
+
+	msg := amqp.Publishing{
+		ContentType:  d.ContentType,
+		MessageId:    d.MessageId,
+		DeliveryMode: amqp.Persistent,
+		Body:         d.Body,
+	}
+
+	if attempts >= w.MaxRetries || errors.Is(cause, errMalformedMessage) {
+		headers[headerRetryCount] = int32(attempts)
+		headers[headerFailedAt] = w.clock.Now().UTC().Format(time.RFC3339)
+		msg.Headers = headers
+		log.Printf("Dead-lettering message after %d attempts: %v", attempts, cause)
+		return publishConfirmed(ch, DeadLetterExchangeName, "", msg)
+	}
+
+	// Note: RabbitMQ only expires messages at the head of a queue, so a
+	// long delay can hold back shorter ones behind it. That only ever
+	// delays retries, it never drops them.
+	headers[headerRetryCount] = int32(attempts + 1)
+	msg.Headers = headers
+	msg.Expiration = strconv.FormatInt(w.backoff(attempts).Milliseconds(), 10)
+	return publishConfirmed(ch, "", RetryQueueName, msg)
+}
+
+// publishConfirmed publishes msg on a confirm-mode channel and waits for
+// the broker's ack. A channel that closes first fails the confirm.
+func publishConfirmed(ch *amqp.Channel, exchange, key string, msg amqp.Publishing) error {
+	conf, err := ch.PublishWithDeferredConfirmWithContext(context.Background(), exchange, key, false, false, msg)
+	if err != nil {
+		return err
+	}
+	if !conf.Wait() {
+		return errPublishNacked
+	}
 	return nil
 }
 
-type EventPayload struct {
-	EventType     string `json:"event_type"`
-	ReservationID string `json:"reservation_id"`
-	EventID       string `json:"event_id"`
-	StartTime     string `json:"start_time"` // Simplified: string in JSON
-	Status        string `json:"status"`     // Inferred or passed
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := w.BaseBackoff
+	for i := 0; i < attempts && delay < w.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > w.MaxBackoff {
+		delay = w.MaxBackoff
+	}
+	return delay
 }
 
-func (w *Worker) processMessage(body []byte) error {
-	var event EventPayload
-	if err := json.Unmarshal(body, &event); err != nil {
+func (w *Worker) processMessage(d amqp.Delivery) error {
+	// Accepts plain envelopes and CloudEvents in either mode
+	envelope, err := decodeMessage(d.ContentType, d.Headers, d.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errMalformedMessage, err)
+	}
+	if envelope.Type == "" {
+		// A bare legacy body; the upcaster reads the flat fields
+		envelope = domain.Envelope{Data: d.Body}
+	}
+
+	// Bring older schema versions up to the structs below. A newer version
+	// is retried: it becomes readable once this worker is upgraded.
+	envelope, err = domain.Upcast(envelope)
+	if errors.Is(err, domain.ErrUnsupportedSchemaVersion) {
 		return err
 	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", errMalformedMessage, err)
+	}
+
+	event, err := envelope.DecodeEvent()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", errMalformedMessage, envelope.Type, err)
+	}
+
+	ctx := context.Background()
+	switch e := event.(type) {
+	case *domain.ReservationCreated:
+		return w.project(ctx, e.ReservationSnapshot, envelope.Version, time.Time{})
+	case *domain.ReservationCancelled:
+		return w.project(ctx, e.ReservationSnapshot, envelope.Version, time.Time{})
+	case *domain.ReservationModified:
+		return w.project(ctx, e.ReservationSnapshot, envelope.Version, e.PreviousStartTime)
+	case *domain.ReservationCompleted:
+		return w.project(ctx, e.ReservationSnapshot, envelope.Version, time.Time{})
+	case *domain.ReservationConfirmed:
+		return w.project(ctx, e.ReservationSnapshot, envelope.Version, time.Time{})
+	case *domain.ReservationHoldExpired:
+		return w.project(ctx, e.ReservationSnapshot, envelope.Version, time.Time{})
+	case *domain.ReservationCheckedIn:
+		return w.project(ctx, e.ReservationSnapshot, envelope.Version, time.Time{})
+	case *domain.ReservationNoShow:
+		return w.project(ctx, e.ReservationSnapshot, envelope.Version, time.Time{})
+	}
+	return fmt.Errorf("%w: unhandled event type %s", errMalformedMessage, envelope.Type)
+}
 
-	// Update Read Model
-	// Assuming raw timestamp string from JSON (RFC3339)
-	status := "BOOKED" // Default for creation event
-	if event.EventType == "ReservationCancelled" {
-		status = "CANCELLED"
-	} else if event.Status != "" {
-		status = event.Status
+// project writes the reservation state carried by an event to the read
+// model. Writes only go through if they are newer than the version last
+// applied for the reservation, wherever its item lives, so redeliveries
+// and retries overtaken by later events are harmless.
+func (w *Worker) project(ctx context.Context, snapshot domain.ReservationSnapshot, version int, previousStart time.Time) error {
+	if snapshot.ReservationID == "" || snapshot.EventID == "" {
+		return fmt.Errorf("%w: missing reservation_id or event_id", errMalformedMessage)
 	}
 
-	// Make idempotent write to DynamoDB
-	return w.dynamoRepo.SaveReadModel(context.Background(), event.ReservationID, event.EventID, event.StartTime, status)
+	res := ProjectSnapshot(snapshot, version, w.clock.Now())
+
+	// A reschedule changes the sort key, so the old item has to go. Keys
+	// have second precision; finer differences address the same item.
+	if !previousStart.IsZero() && !previousStart.Truncate(time.Second).Equal(snapshot.StartTime.Truncate(time.Second)) {
+		return w.readModel.MoveReadModel(ctx, res, previousStart)
+	}
+	return w.readModel.SaveReadModel(ctx, res)
+}
+
+// ProjectSnapshot maps the reservation state carried by an event onto its
+// read model view, stamped as updated at now. The rebuild command uses it
+// too, so replayed items are identical to live ones.
+func ProjectSnapshot(snapshot domain.ReservationSnapshot, version int, now time.Time) *domain.Reservation {
+	return &domain.Reservation{
+		ID:          snapshot.ReservationID,
+		UserID:      snapshot.UserID,
+		EventID:     snapshot.EventID,
+		StartTime:   snapshot.StartTime,
+		EndTime:     snapshot.EndTime,
+		TicketCount: snapshot.TicketCount,
+		Status:      snapshot.Status,
+		UpdatedAt:   now,
+		ExpiresAt:   snapshot.ExpiresAt,
+		Version:     version,
+	}
 }
@@ -2,15 +2,41 @@ package repositories
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
 )
 
+const (
+	defaultPageSize = 50
+
+	// maxBatchWriteItems is the BatchWriteItem request limit.
+	maxBatchWriteItems = 25
+	// maxBatchWriteAttempts bounds retries of throttled (unprocessed) items.
+	maxBatchWriteAttempts = 8
+)
+
+// maxApplyAttempts bounds how often a write is retried when another
+// writer changes the same reservation between the read of its version and
+// the transaction.
+const maxApplyAttempts = 5
+
+// ErrReadModelSuperseded is returned by RepairReadModel and
+// DeleteReadModel when the item changed since it was read, so the write
+// was skipped rather than applied.
+var ErrReadModelSuperseded = errors.New("read model item superseded")
+
 type DynamoDBReservationRepository struct {
 	client    *dynamodb.Client
 	tableName string
@@ -26,25 +52,447 @@ func NewDynamoDBReservationRepository(client *dynamodb.Client, tableName string)
 // SaveReadModel writes an optimized read view of the reservation.
 // PK: EVENT#<event_id>
 // SK: RES#<start_time>#<reservation_id>
-func (r *DynamoDBReservationRepository) SaveReadModel(ctx context.Context, reservationID, eventID, startTime, status string) error {
-	pk := fmt.Sprintf("EVENT#%s", eventID)
-	// ISO8601 strings sort lexicographically
-	sk := fmt.Sprintf("RES#%s#%s", startTime, reservationID)
-
-	_, err := r.client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(r.tableName),
-		Item: map[string]types.AttributeValue{
-			"PK":            &types.AttributeValueMemberS{Value: pk},
-			"SK":            &types.AttributeValueMemberS{Value: sk},
-			"ReservationID": &types.AttributeValueMemberS{Value: reservationID},
-			"Status":        &types.AttributeValueMemberS{Value: status},
-			"UpdatedAt":     &types.AttributeValueMemberS{Value: time.Now().Format(time.RFC3339)},
-		},
+// Listing items move when a reservation is rescheduled, so the last
+// version applied is kept on a separate item that does not:
+// PK: RES#<reservation_id>
+// SK: VERSION
+// The write is skipped when that version is the same or newer, so
+// duplicate and stale deliveries are harmless, and it removes the listing
+// item at the slot the reservation was last written to.
+func (r *DynamoDBReservationRepository) SaveReadModel(ctx context.Context, res *domain.Reservation) error {
+	_, err := r.apply(ctx, res, time.Time{}, false)
+	return err
+}
+
+// MoveReadModel is SaveReadModel for a rescheduled reservation. It also
+// removes the item at oldStartTime, which items written before version
+// tracking existed need.
+func (r *DynamoDBReservationRepository) MoveReadModel(ctx context.Context, res *domain.Reservation, oldStartTime time.Time) error {
+	_, err := r.apply(ctx, res, oldStartTime, false)
+	return err
+}
+
+// RepairReadModel is SaveReadModel for an item known to be wrong: it also
+// replaces an item at the same version, but never a newer one, so it is
+// safe while the worker is projecting live changes. It returns
+// ErrReadModelSuperseded when a newer version is already stored.
+func (r *DynamoDBReservationRepository) RepairReadModel(ctx context.Context, res *domain.Reservation) error {
+	written, err := r.apply(ctx, res, time.Time{}, true)
+	if err == nil && !written {
+		return ErrReadModelSuperseded
+	}
+	return err
+}
+
+// SaveReadModels writes many read model items with the same version
+// check as SaveReadModel, one at a time. Use it for a table the worker is
+// writing to.
+func (r *DynamoDBReservationRepository) SaveReadModels(ctx context.Context, reservations []*domain.Reservation) error {
+	for _, res := range reservations {
+		if err := r.SaveReadModel(ctx, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchSaveReadModel writes many read model items, with their version
+// items, with BatchWriteItem. It checks no versions, so it is only for
+// filling a table nothing else writes to. Items DynamoDB returns as
+// unprocessed, usually because the table is throttling, are retried with
+// exponential backoff.
+func (r *DynamoDBReservationRepository) BatchSaveReadModel(ctx context.Context, reservations []*domain.Reservation) error {
+	requests := make([]types.WriteRequest, 0, 2*len(reservations))
+	for _, res := range reservations {
+		requests = append(requests,
+			types.WriteRequest{PutRequest: &types.PutRequest{Item: readModelItem(res)}},
+			types.WriteRequest{PutRequest: &types.PutRequest{Item: versionItem(res)}},
+		)
+	}
+
+	for start := 0; start < len(requests); start += maxBatchWriteItems {
+		if err := r.batchWrite(ctx, requests[start:min(start+maxBatchWriteItems, len(requests))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *DynamoDBReservationRepository) batchWrite(ctx context.Context, requests []types.WriteRequest) error {
+	delay := 50 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		out, err := r.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{r.tableName: requests},
+		})
+		if err != nil {
+			return err
+		}
+
+		requests = out.UnprocessedItems[r.tableName]
+		if len(requests) == 0 {
+			return nil
+		}
+		if attempt == maxBatchWriteAttempts {
+			return fmt.Errorf("%d items still unprocessed after %d attempts", len(requests), attempt)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// DeleteReadModel removes the item stored for res at its start time, as
+// long as it is still at res.Version. An item that changed or vanished
+// since it was read is left alone and ErrReadModelSuperseded returned.
+func (r *DynamoDBReservationRepository) DeleteReadModel(ctx context.Context, res *domain.Reservation) error {
+	_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 aws.String(r.tableName),
+		Key:                       readModelKey(res.ID, res.EventID, res.StartTime),
+		ConditionExpression:       aws.String("Version = :version"),
+		ExpressionAttributeValues: versionValue(res.Version),
 	})
 
+	var changed *types.ConditionalCheckFailedException
+	if errors.As(err, &changed) {
+		return ErrReadModelSuperseded
+	}
+	return err
+}
+
+// apply writes res unless the reservation's recorded version is newer (or
+// the same, unless replaceSame), in one transaction that bumps the version
+// item, puts the listing item and deletes the listing items at the slots
+// res no longer occupies. It reports whether anything was written.
+func (r *DynamoDBReservationRepository) apply(ctx context.Context, res *domain.Reservation, previousStart time.Time, replaceSame bool) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		stored, err := r.appliedVersion(ctx, res.ID)
+		if err != nil {
+			return false, err
+		}
+		if stored != nil && (stored.Version > res.Version || stored.Version == res.Version && !replaceSame) {
+			return false, nil
+		}
+
+		// The version item only changes if nobody wrote it since it was read
+		versionPut := &types.Put{
+			TableName:           aws.String(r.tableName),
+			Item:                versionItem(res),
+			ConditionExpression: aws.String("attribute_not_exists(Version)"),
+		}
+		if stored != nil {
+			versionPut.ConditionExpression = aws.String("Version = :version")
+			versionPut.ExpressionAttributeValues = versionValue(stored.Version)
+		}
+		items := []types.TransactWriteItem{
+			{Put: versionPut},
+			{Put: &types.Put{TableName: aws.String(r.tableName), Item: readModelItem(res)}},
+		}
+		for _, key := range staleKeys(res, stored, previousStart) {
+			items = append(items, types.TransactWriteItem{
+				Delete: &types.Delete{TableName: aws.String(r.tableName), Key: key},
+			})
+		}
+
+		_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+		if err == nil {
+			return true, nil
+		}
+		if !transactionRaced(err) || attempt == maxApplyAttempts {
+			log.Printf("Failed to write to DynamoDB: %v", err)
+			return false, err
+		}
+	}
+}
+
+// appliedVersion reads the version item of a reservation: the version last
+// applied and the event and start time it was written under. It returns
+// nil when nothing was written yet.
+func (r *DynamoDBReservationRepository) appliedVersion(ctx context.Context, reservationID string) (*domain.Reservation, error) {
+	out, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tableName),
+		Key:            versionKey(reservationID),
+		ConsistentRead: aws.Bool(true),
+	})
 	if err != nil {
-		log.Printf("Failed to write to DynamoDB: %v", err)
-		return err
+		return nil, err
 	}
-	return nil
+	if len(out.Item) == 0 {
+		return nil, nil
+	}
+	return reservationFromItem(out.Item), nil
+}
+
+// staleKeys lists the listing items of res other than the one being
+// written: where stored says it was last written, and previousStart.
+func staleKeys(res, stored *domain.Reservation, previousStart time.Time) []map[string]types.AttributeValue {
+	current := readModelSK(res.ID, res.StartTime)
+	seen := map[string]bool{"EVENT#" + res.EventID + "|" + current: true}
+
+	var keys []map[string]types.AttributeValue
+	add := func(eventID string, start time.Time) {
+		if eventID == "" || start.IsZero() {
+			return
+		}
+		id := "EVENT#" + eventID + "|" + readModelSK(res.ID, start)
+		if !seen[id] {
+			seen[id] = true
+			keys = append(keys, readModelKey(res.ID, eventID, start))
+		}
+	}
+	if stored != nil {
+		add(stored.EventID, stored.StartTime)
+	}
+	add(res.EventID, previousStart)
+	return keys
+}
+
+func versionValue(version int) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		":version": &types.AttributeValueMemberN{Value: strconv.Itoa(version)},
+	}
+}
+
+// transactionRaced reports whether a transaction was cancelled because
+// another writer got to one of its items first, so it can be retried.
+func transactionRaced(err error) bool {
+	var cancelled *types.TransactionCanceledException
+	if !errors.As(err, &cancelled) {
+		return false
+	}
+	for _, reason := range cancelled.CancellationReasons {
+		switch aws.ToString(reason.Code) {
+		case "ConditionalCheckFailed", "TransactionConflict":
+			return true
+		}
+	}
+	return false
+}
+
+// ListByEvent range-queries the read model for reservations of an event
+// starting in [start, end), skipping cancelled and expired ones like the
+// Postgres query.
+// pageToken is the opaque NextPageToken of a previous page.
+func (r *DynamoDBReservationRepository) ListByEvent(ctx context.Context, eventID string, start, end time.Time, limit int, pageToken string) (*ports.ReservationPage, error) {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+
+	startKey, err := decodePageToken(pageToken, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	// DynamoDB applies Limit before the filter, so a single query can come
+	// back short or empty. Keep going until the page is full or the range
+	// is exhausted; each query evaluates at most the items still needed,
+	// so LastEvaluatedKey never skips past a matching item.
+	page := &ports.ReservationPage{Items: make([]*domain.Reservation, 0, limit)}
+	for {
+		// "RES#<end>" sorts before any "RES#<end>#<id>", making the upper bound exclusive
+		out, err := r.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			KeyConditionExpression: aws.String("PK = :pk AND SK BETWEEN :from AND :to"),
+			FilterExpression:       aws.String("NOT #status IN (:cancelled, :expired)"),
+			ExpressionAttributeNames: map[string]string{
+				"#status": "Status",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":        &types.AttributeValueMemberS{Value: "EVENT#" + eventID},
+				":from":      &types.AttributeValueMemberS{Value: "RES#" + formatReadModelTime(start)},
+				":to":        &types.AttributeValueMemberS{Value: "RES#" + formatReadModelTime(end)},
+				":cancelled": &types.AttributeValueMemberS{Value: string(domain.StatusCancelled)},
+				":expired":   &types.AttributeValueMemberS{Value: string(domain.StatusExpired)},
+			},
+			Limit:             aws.Int32(int32(limit - len(page.Items))),
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range out.Items {
+			page.Items = append(page.Items, reservationFromItem(item))
+		}
+
+		startKey = out.LastEvaluatedKey
+		if len(startKey) == 0 || len(page.Items) >= limit {
+			break
+		}
+	}
+
+	page.NextPageToken, err = encodePageToken(startKey)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// ListAllByEvent returns every read model item of an event starting in
+// [start, end), cancelled ones included, following pages internally.
+func (r *DynamoDBReservationRepository) ListAllByEvent(ctx context.Context, eventID string, start, end time.Time) ([]*domain.Reservation, error) {
+	var reservations []*domain.Reservation
+	var startKey map[string]types.AttributeValue
+	for {
+		out, err := r.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			KeyConditionExpression: aws.String("PK = :pk AND SK BETWEEN :from AND :to"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":   &types.AttributeValueMemberS{Value: "EVENT#" + eventID},
+				":from": &types.AttributeValueMemberS{Value: "RES#" + formatReadModelTime(start)},
+				":to":   &types.AttributeValueMemberS{Value: "RES#" + formatReadModelTime(end)},
+			},
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range out.Items {
+			reservations = append(reservations, reservationFromItem(item))
+		}
+
+		if len(out.LastEvaluatedKey) == 0 {
+			return reservations, nil
+		}
+		startKey = out.LastEvaluatedKey
+	}
+}
+
+func readModelKey(reservationID, eventID string, startTime time.Time) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: fmt.Sprintf("EVENT#%s", eventID)},
+		"SK": &types.AttributeValueMemberS{Value: readModelSK(reservationID, startTime)},
+	}
+}
+
+// readModelSK sorts listing items by start time; ISO8601 strings sort
+// lexicographically.
+func readModelSK(reservationID string, startTime time.Time) string {
+	return fmt.Sprintf("RES#%s#%s", formatReadModelTime(startTime), reservationID)
+}
+
+func versionKey(reservationID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: fmt.Sprintf("RES#%s", reservationID)},
+		"SK": &types.AttributeValueMemberS{Value: "VERSION"},
+	}
+}
+
+// versionItem records that res.Version was applied and where its listing
+// item lives, in the attributes reservationFromItem reads.
+func versionItem(res *domain.Reservation) map[string]types.AttributeValue {
+	item := versionKey(res.ID)
+	item["ReservationID"] = &types.AttributeValueMemberS{Value: res.ID}
+	item["EventID"] = &types.AttributeValueMemberS{Value: res.EventID}
+	item["StartTime"] = &types.AttributeValueMemberS{Value: formatReadModelTime(res.StartTime)}
+	item["Version"] = &types.AttributeValueMemberN{Value: strconv.Itoa(res.Version)}
+	return item
+}
+
+func readModelItem(res *domain.Reservation) map[string]types.AttributeValue {
+	item := readModelKey(res.ID, res.EventID, res.StartTime)
+	item["ReservationID"] = &types.AttributeValueMemberS{Value: res.ID}
+	item["EventID"] = &types.AttributeValueMemberS{Value: res.EventID}
+	item["UserID"] = &types.AttributeValueMemberS{Value: res.UserID}
+	item["StartTime"] = &types.AttributeValueMemberS{Value: formatReadModelTime(res.StartTime)}
+	item["EndTime"] = &types.AttributeValueMemberS{Value: formatReadModelTime(res.EndTime)}
+	item["TicketCount"] = &types.AttributeValueMemberN{Value: strconv.Itoa(res.TicketCount)}
+	item["Status"] = &types.AttributeValueMemberS{Value: string(res.Status)}
+	item["Version"] = &types.AttributeValueMemberN{Value: strconv.Itoa(res.Version)}
+	item["UpdatedAt"] = &types.AttributeValueMemberS{Value: formatReadModelTime(res.UpdatedAt)}
+	if res.ExpiresAt != nil {
+		item["ExpiresAt"] = &types.AttributeValueMemberS{Value: formatReadModelTime(*res.ExpiresAt)}
+	}
+	return item
+}
+
+func reservationFromItem(item map[string]types.AttributeValue) *domain.Reservation {
+	str := func(name string) string {
+		if v, ok := item[name].(*types.AttributeValueMemberS); ok {
+			return v.Value
+		}
+		return ""
+	}
+	num := func(name string) int {
+		if v, ok := item[name].(*types.AttributeValueMemberN); ok {
+			n, _ := strconv.Atoi(v.Value)
+			return n
+		}
+		return 0
+	}
+	// Items written before these attributes existed simply leave them zero
+	parseTime := func(name string) time.Time {
+		t, _ := time.Parse(time.RFC3339, str(name))
+		return t
+	}
+
+	var expiresAt *time.Time
+	if t := parseTime("ExpiresAt"); !t.IsZero() {
+		expiresAt = &t
+	}
+
+	return &domain.Reservation{
+		ID:          str("ReservationID"),
+		UserID:      str("UserID"),
+		EventID:     str("EventID"),
+		StartTime:   parseTime("StartTime"),
+		EndTime:     parseTime("EndTime"),
+		TicketCount: num("TicketCount"),
+		Status:      domain.ReservationStatus(str("Status")),
+		UpdatedAt:   parseTime("UpdatedAt"),
+		ExpiresAt:   expiresAt,
+		Version:     num("Version"),
+	}
+}
+
+func formatReadModelTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Page tokens are the base64-encoded LastEvaluatedKey (PK and SK strings).
+func encodePageToken(key map[string]types.AttributeValue) (string, error) {
+	if len(key) == 0 {
+		return "", nil
+	}
+
+	plain := map[string]string{}
+	for name, v := range key {
+		if s, ok := v.(*types.AttributeValueMemberS); ok {
+			plain[name] = s.Value
+		}
+	}
+	b, err := json.Marshal(plain)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodePageToken also checks that the token was issued for eventID's
+// partition, so a token cannot be replayed against another event.
+func decodePageToken(token, eventID string) (map[string]types.AttributeValue, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ports.ErrInvalidPageToken
+	}
+	var plain map[string]string
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return nil, ports.ErrInvalidPageToken
+	}
+	if plain["PK"] != "EVENT#"+eventID || !strings.HasPrefix(plain["SK"], "RES#") {
+		return nil, ports.ErrInvalidPageToken
+	}
+
+	key := map[string]types.AttributeValue{}
+	for name, v := range plain {
+		key[name] = &types.AttributeValueMemberS{Value: v}
+	}
+	return key, nil
 }
@@ -0,0 +1,97 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/repositories"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/repotest"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/bootstrap"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/config"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/google/uuid"
+)
+
+// openTestTable creates a throwaway table on the DynamoDB endpoint in
+// TEST_DYNAMODB_ENDPOINT (e.g. LocalStack), skipping the test without one.
+func openTestTable(t *testing.T) *repositories.DynamoDBReservationRepository {
+	t.Helper()
+	endpoint := os.Getenv("TEST_DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("TEST_DYNAMODB_ENDPOINT not set")
+	}
+
+	ctx := context.Background()
+	client, err := bootstrap.NewDynamoDBClient(ctx, config.AWSConfig{
+		Region:          "us-east-1",
+		EndpointURL:     endpoint,
+		AccessKeyID:     "test",
+		SecretAccessKey: "test",
+	})
+	if err != nil {
+		t.Fatalf("NewDynamoDBClient: %v", err)
+	}
+
+	table := "ReadModelTest-" + uuid.New().String()[:8]
+	_, err = client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String(table),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("PK"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("SK"), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("PK"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("SK"), KeyType: types.KeyTypeRange},
+		},
+		BillingMode: types.BillingModePayPerRequest,
+	})
+	if err != nil {
+		t.Fatalf("CreateTable: %v", err)
+	}
+	t.Cleanup(func() {
+		client.DeleteTable(context.Background(), &dynamodb.DeleteTableInput{TableName: aws.String(table)})
+	})
+	waiter := dynamodb.NewTableExistsWaiter(client)
+	if err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(table)}, time.Minute); err != nil {
+		t.Fatalf("table %s did not become active: %v", table, err)
+	}
+	return repositories.NewDynamoDBReservationRepository(client, table)
+}
+
+func TestReadModelContract(t *testing.T) {
+	repotest.RunReadModel(t, func(t *testing.T) repotest.ReadModel {
+		return openTestTable(t)
+	})
+}
+
+func TestRepairSkipsItemsChangedSinceRead(t *testing.T) {
+	repo := openTestTable(t)
+	ctx := context.Background()
+	start := time.Date(2030, 1, 10, 18, 0, 0, 0, time.UTC)
+	res := &domain.Reservation{
+		ID: uuid.New().String(), UserID: "user-1", EventID: uuid.New().String(),
+		StartTime: start, EndTime: start.Add(time.Hour), TicketCount: 1,
+		Status: domain.StatusCheckedIn, Version: 2,
+	}
+	if err := repo.SaveReadModel(ctx, res); err != nil {
+		t.Fatalf("SaveReadModel: %v", err)
+	}
+
+	stale := *res
+	stale.Status, stale.Version = domain.StatusBooked, 1
+	if err := repo.RepairReadModel(ctx, &stale); !errors.Is(err, repositories.ErrReadModelSuperseded) {
+		t.Errorf("RepairReadModel of an older version = %v; want ErrReadModelSuperseded", err)
+	}
+	if err := repo.DeleteReadModel(ctx, &stale); !errors.Is(err, repositories.ErrReadModelSuperseded) {
+		t.Errorf("DeleteReadModel of an older version = %v; want ErrReadModelSuperseded", err)
+	}
+	if err := repo.RepairReadModel(ctx, res); err != nil {
+		t.Errorf("RepairReadModel at the stored version: %v", err)
+	}
+}
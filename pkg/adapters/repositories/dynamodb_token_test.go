@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+)
+
+func TestDecodePageToken(t *testing.T) {
+	token, err := encodePageToken(map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "EVENT#event-1"},
+		"SK": &types.AttributeValueMemberS{Value: "RES#2030-01-10T18:00:00Z#res-1"},
+	})
+	if err != nil {
+		t.Fatalf("encodePageToken: %v", err)
+	}
+
+	key, err := decodePageToken(token, "event-1")
+	if err != nil {
+		t.Fatalf("decodePageToken for the issuing event: %v", err)
+	}
+	if sk := key["SK"].(*types.AttributeValueMemberS).Value; sk != "RES#2030-01-10T18:00:00Z#res-1" {
+		t.Errorf("SK = %q", sk)
+	}
+
+	for name, tt := range map[string]struct{ token, eventID string }{
+		"other event":  {token, "event-2"},
+		"not base64":   {"%%%", "event-1"},
+		"version item": {mustEncode(t, "RES#res-1", "VERSION"), "event-1"},
+		"no key":       {mustEncode(t, "", ""), "event-1"},
+	} {
+		if _, err := decodePageToken(tt.token, tt.eventID); !errors.Is(err, ports.ErrInvalidPageToken) {
+			t.Errorf("%s: decodePageToken = %v; want ErrInvalidPageToken", name, err)
+		}
+	}
+}
+
+func mustEncode(t *testing.T, pk, sk string) string {
+	t.Helper()
+	token, err := encodePageToken(map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: pk},
+		"SK": &types.AttributeValueMemberS{Value: sk},
+	})
+	if err != nil {
+		t.Fatalf("encodePageToken: %v", err)
+	}
+	return token
+}
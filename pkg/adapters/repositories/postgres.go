@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
 	_ "github.com/lib/pq" // Postgres driver
 )
 
@@ -17,45 +18,249 @@ func NewPostgresReservationRepository(db *sql.DB) *PostgresReservationRepository
 	return &PostgresReservationRepository{db: db}
 }
 
-func (r *PostgresReservationRepository) Save(ctx context.Context, res *domain.Reservation) error {
+func (r *PostgresReservationRepository) Save(ctx context.Context, res *domain.Reservation, events ...ports.OutboxMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := reserveCapacity(ctx, tx, res); err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO reservations (id, user_id, event_id, start_time, end_time, ticket_count, status, version, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO reservations (id, user_id, event_id, start_time, end_time, ticket_count, status, version, created_at, updated_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
-	_, err := r.db.ExecContext(ctx, query,
-		res.ID, res.UserID, res.EventID, res.StartTime, res.EndTime, res.TicketCount, res.Status, res.Version, res.CreatedAt, res.UpdatedAt,
+	_, err = tx.ExecContext(ctx, query,
+		res.ID, res.UserID, res.EventID, res.StartTime, res.EndTime, res.TicketCount, res.Status, res.Version, res.CreatedAt, res.UpdatedAt, res.ExpiresAt,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if err := insertTransitions(ctx, tx, res.PendingTransitions()); err != nil {
+		return err
+	}
+	if err := insertOutbox(ctx, tx, events); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	res.ClearPendingTransitions()
+	return nil
 }
 
-func (r *PostgresReservationRepository) GetByID(ctx context.Context, id string) (*domain.Reservation, error) {
+func (r *PostgresReservationRepository) Update(ctx context.Context, res *domain.Reservation, events ...ports.OutboxMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the row so the capacity decision holds until commit. Either the
+	// row is gone or someone else bumped the version first.
+	var stored domain.Reservation
+	err = tx.QueryRowContext(ctx,
+		`SELECT start_time, ticket_count, status FROM reservations WHERE id = $1 AND version = $2 FOR UPDATE`,
+		res.ID, res.Version,
+	).Scan(&stored.StartTime, &stored.TicketCount, &stored.Status)
+	if err == sql.ErrNoRows {
+		return domain.ErrVersionConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	// Status-only changes such as check-in leave the event row unlocked
+	if res.NeedsCapacityCheck(&stored) {
+		if err := reserveCapacity(ctx, tx, res); err != nil {
+			return err
+		}
+	}
+
 	query := `
-		SELECT id, user_id, event_id, start_time, end_time, ticket_count, status, version, created_at, updated_at
-		FROM reservations WHERE id = $1
+		UPDATE reservations
+		SET start_time = $3, end_time = $4, ticket_count = $5, status = $6, updated_at = $7, expires_at = $8, version = version + 1
+		WHERE id = $1 AND version = $2
 	`
-	row := r.db.QueryRowContext(ctx, query, id)
-
-	var res domain.Reservation
-	err := row.Scan(
-		&res.ID, &res.UserID, &res.EventID, &res.StartTime, &res.EndTime, &res.TicketCount, &res.Status, &res.Version, &res.CreatedAt, &res.UpdatedAt,
+	result, err := tx.ExecContext(ctx, query,
+		res.ID, res.Version, res.StartTime, res.EndTime, res.TicketCount, res.Status, res.UpdatedAt, res.ExpiresAt,
 	)
+	if err != nil {
+		return err
+	}
+
+	if err := requireAffected(result, domain.ErrVersionConflict); err != nil {
+		return err
+	}
+
+	if err := insertTransitions(ctx, tx, res.PendingTransitions()); err != nil {
+		return err
+	}
+	if err := insertOutbox(ctx, tx, events); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	res.Version++
+	res.ClearPendingTransitions()
+	return nil
+}
+
+func insertTransitions(ctx context.Context, tx *sql.Tx, transitions []domain.Transition) error {
+	query := `
+		INSERT INTO reservation_transitions (reservation_id, from_status, to_status, actor, reason, occurred_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''), $6)
+	`
+	for _, t := range transitions {
+		if _, err := tx.ExecContext(ctx, query, t.ReservationID, t.From, t.To, t.Actor, t.Reason, t.At); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reserveCapacity checks that res fits within its event's limits. The event
+// row is locked FOR UPDATE, so concurrent bookings for the same event are
+// serialised until tx commits and cannot both claim the last seats. Holds
+// stop counting once they lapse, even before the worker expires them;
+// lapsed is judged at res.UpdatedAt, the service's time of the change.
+func reserveCapacity(ctx context.Context, tx *sql.Tx, res *domain.Reservation) error {
+	if res.Status.Released() {
+		return nil
+	}
+
+	var capacity, slotCapacity int
+	err := tx.QueryRowContext(ctx,
+		`SELECT capacity, slot_capacity FROM events WHERE id = $1 FOR UPDATE`, res.EventID,
+	).Scan(&capacity, &slotCapacity)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrEventNotFound
+		}
+		return err
+	}
+	if capacity == 0 && slotCapacity == 0 {
+		return nil
+	}
+
+	// Exclude the reservation itself so updates don't count their old tickets
+	query := `
+		SELECT
+			COALESCE(SUM(ticket_count), 0),
+			COALESCE(SUM(ticket_count) FILTER (WHERE start_time = $2), 0)
+		FROM reservations
+		WHERE event_id = $1 AND id != $3 AND status NOT IN ('CANCELLED', 'EXPIRED')
+			AND NOT (status = 'HELD' AND expires_at <= $4)
+	`
+	var booked, slotBooked int
+	if err := tx.QueryRowContext(ctx, query, res.EventID, res.StartTime, res.ID, res.UpdatedAt).Scan(&booked, &slotBooked); err != nil {
+		return err
+	}
+
+	if capacity > 0 && booked+res.TicketCount > capacity {
+		return domain.ErrCapacityExceeded
+	}
+	if slotCapacity > 0 && slotBooked+res.TicketCount > slotCapacity {
+		return domain.ErrCapacityExceeded
+	}
+	return nil
+}
+
+func (r *PostgresReservationRepository) GetByID(ctx context.Context, id string) (*domain.Reservation, error) {
+	query := `SELECT ` + reservationColumns + ` FROM reservations WHERE id = $1`
+
+	res, err := scanReservation(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
-	return &res, nil
+	return res, nil
 }
 
 func (r *PostgresReservationRepository) GetByEventAndRange(ctx context.Context, eventID string, start, end time.Time) ([]*domain.Reservation, error) {
 	query := `
-		SELECT id, user_id, event_id, start_time, end_time, ticket_count, status, version, created_at, updated_at
-		FROM reservations 
-		WHERE event_id = $1 AND start_time >= $2 AND start_time < $3 AND status != 'CANCELLED'
+		SELECT ` + reservationColumns + `
+		FROM reservations
+		WHERE event_id = $1 AND start_time >= $2 AND start_time < $3 AND status NOT IN ('CANCELLED', 'EXPIRED')
 		ORDER BY start_time ASC
 	`
-	rows, err := r.db.QueryContext(ctx, query, eventID, start, end)
+	return r.query(ctx, query, eventID, start, end)
+}
+
+// GetAllByEventAndRange is GetByEventAndRange including cancelled and
+// expired reservations, which the read model keeps as well.
+func (r *PostgresReservationRepository) GetAllByEventAndRange(ctx context.Context, eventID string, start, end time.Time) ([]*domain.Reservation, error) {
+	query := `
+		SELECT ` + reservationColumns + `
+		FROM reservations
+		WHERE event_id = $1 AND start_time >= $2 AND start_time < $3
+		ORDER BY start_time ASC
+	`
+	return r.query(ctx, query, eventID, start, end)
+}
+
+// ListAfter pages through every reservation, cancelled ones included, in id
+// order. Pass the last id of the previous page as afterID ("" to start).
+func (r *PostgresReservationRepository) ListAfter(ctx context.Context, afterID string, limit int) ([]*domain.Reservation, error) {
+	query := `
+		SELECT ` + reservationColumns + `
+		FROM reservations
+		WHERE id > $1
+		ORDER BY id ASC
+		LIMIT $2
+	`
+	return r.query(ctx, query, afterID, limit)
+}
+
+func (r *PostgresReservationRepository) ListExpiredHolds(ctx context.Context, now time.Time, limit int) ([]*domain.Reservation, error) {
+	query := `
+		SELECT ` + reservationColumns + `
+		FROM reservations
+		WHERE status = 'HELD' AND expires_at <= $1
+		ORDER BY expires_at ASC
+		LIMIT $2
+	`
+	return r.query(ctx, query, now, limit)
+}
+
+// ListTransitions returns the status changes of a reservation, oldest first.
+func (r *PostgresReservationRepository) ListTransitions(ctx context.Context, reservationID string) ([]domain.Transition, error) {
+	query := `
+		SELECT reservation_id, COALESCE(from_status, ''), to_status, actor, COALESCE(reason, ''), occurred_at
+		FROM reservation_transitions
+		WHERE reservation_id = $1
+		ORDER BY id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transitions []domain.Transition
+	for rows.Next() {
+		var t domain.Transition
+		if err := rows.Scan(&t.ReservationID, &t.From, &t.To, &t.Actor, &t.Reason, &t.At); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, t)
+	}
+	return transitions, rows.Err()
+}
+
+const reservationColumns = `id, user_id, event_id, start_time, end_time, ticket_count, status, version, created_at, updated_at, expires_at`
+
+func (r *PostgresReservationRepository) query(ctx context.Context, query string, args ...any) ([]*domain.Reservation, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -63,13 +268,26 @@ func (r *PostgresReservationRepository) GetByEventAndRange(ctx context.Context,
 
 	var reservations []*domain.Reservation
 	for rows.Next() {
-		var res domain.Reservation
-		if err := rows.Scan(
-			&res.ID, &res.UserID, &res.EventID, &res.StartTime, &res.EndTime, &res.TicketCount, &res.Status, &res.Version, &res.CreatedAt, &res.UpdatedAt,
-		); err != nil {
+		res, err := scanReservation(rows)
+		if err != nil {
 			return nil, err
 		}
-		reservations = append(reservations, &res)
+		reservations = append(reservations, res)
+	}
+	return reservations, rows.Err()
+}
+
+// scanReservation reads a row selected with reservationColumns.
+func scanReservation(row interface{ Scan(...any) error }) (*domain.Reservation, error) {
+	var res domain.Reservation
+	var expiresAt sql.NullTime
+	if err := row.Scan(
+		&res.ID, &res.UserID, &res.EventID, &res.StartTime, &res.EndTime, &res.TicketCount, &res.Status, &res.Version, &res.CreatedAt, &res.UpdatedAt, &expiresAt,
+	); err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		res.ExpiresAt = &expiresAt.Time
 	}
-	return reservations, nil
+	return &res, nil
 }
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+)
+
+type PostgresEventRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresEventRepository(db *sql.DB) *PostgresEventRepository {
+	return &PostgresEventRepository{db: db}
+}
+
+func (r *PostgresEventRepository) Save(ctx context.Context, event *domain.Event) error {
+	query := `
+		INSERT INTO events (id, name, venue, timezone, capacity, slot_capacity, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		event.ID, event.Name, event.Venue, event.Timezone, event.Capacity, event.SlotCapacity, event.CreatedAt, event.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresEventRepository) Update(ctx context.Context, event *domain.Event) error {
+	query := `
+		UPDATE events SET name = $2, venue = $3, timezone = $4, capacity = $5, slot_capacity = $6, updated_at = $7
+		WHERE id = $1
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		event.ID, event.Name, event.Venue, event.Timezone, event.Capacity, event.SlotCapacity, event.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, domain.ErrEventNotFound)
+}
+
+func (r *PostgresEventRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM events WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, domain.ErrEventNotFound)
+}
+
+func (r *PostgresEventRepository) GetByID(ctx context.Context, id string) (*domain.Event, error) {
+	query := `
+		SELECT id, name, COALESCE(venue, ''), timezone, capacity, slot_capacity, created_at, updated_at
+		FROM events WHERE id = $1
+	`
+	var event domain.Event
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&event.ID, &event.Name, &event.Venue, &event.Timezone, &event.Capacity, &event.SlotCapacity, &event.CreatedAt, &event.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *PostgresEventRepository) List(ctx context.Context) ([]*domain.Event, error) {
+	query := `
+		SELECT id, name, COALESCE(venue, ''), timezone, capacity, slot_capacity, created_at, updated_at
+		FROM events
+		ORDER BY name ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*domain.Event{}
+	for rows.Next() {
+		var event domain.Event
+		if err := rows.Scan(
+			&event.ID, &event.Name, &event.Venue, &event.Timezone, &event.Capacity, &event.SlotCapacity, &event.CreatedAt, &event.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
+
+// requireAffected maps an UPDATE/DELETE that touched no rows to notFound.
+func requireAffected(result sql.Result, notFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
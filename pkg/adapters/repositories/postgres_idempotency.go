@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+)
+
+type PostgresIdempotencyStore struct {
+	db *sql.DB
+}
+
+func NewPostgresIdempotencyStore(db *sql.DB) *PostgresIdempotencyStore {
+	return &PostgresIdempotencyStore{db: db}
+}
+
+func (s *PostgresIdempotencyStore) Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*ports.IdempotencyRecord, error) {
+	for {
+		// Expired keys are reclaimed lazily instead of by a cleanup job
+		if _, err := s.db.ExecContext(ctx,
+			`DELETE FROM idempotency_keys WHERE key = $1 AND expires_at < NOW()`, key,
+		); err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		result, err := s.db.ExecContext(ctx, `
+			INSERT INTO idempotency_keys (key, request_hash, created_at, expires_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (key) DO NOTHING
+		`, key, requestHash, now, now.Add(ttl))
+		if err != nil {
+			return nil, err
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 1 {
+			return nil, err
+		}
+
+		var rec ports.IdempotencyRecord
+		err = s.db.QueryRowContext(ctx, `
+			SELECT key, request_hash, status_code, response_body, created_at, expires_at
+			FROM idempotency_keys WHERE key = $1
+		`, key).Scan(&rec.Key, &rec.RequestHash, &rec.StatusCode, &rec.ResponseBody, &rec.CreatedAt, &rec.ExpiresAt)
+		if err == sql.ErrNoRows {
+			// Released or expired since the insert conflicted; claim it again
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &rec, nil
+	}
+}
+
+func (s *PostgresIdempotencyStore) Complete(ctx context.Context, key string, statusCode int, body []byte) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE idempotency_keys SET status_code = $2, response_body = $3 WHERE key = $1`,
+		key, statusCode, body,
+	)
+	return err
+}
+
+func (s *PostgresIdempotencyStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1`, key)
+	return err
+}
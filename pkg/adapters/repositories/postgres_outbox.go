@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+)
+
+type PostgresOutboxRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresOutboxRepository(db *sql.DB) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{db: db}
+}
+
+func (r *PostgresOutboxRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]ports.OutboxMessage, error) {
+	// SKIP LOCKED lets several relays poll at once without blocking each other
+	query := `
+		UPDATE outbox
+		SET locked_until = NOW() + $2 * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE dispatched_at IS NULL AND parked_at IS NULL AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY id ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, aggregate_id, event_type, payload, created_at, attempts
+	`
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []ports.OutboxMessage
+	for rows.Next() {
+		var msg ports.OutboxMessage
+		if err := rows.Scan(&msg.ID, &msg.AggregateID, &msg.EventType, &msg.Payload, &msg.CreatedAt, &msg.Attempts); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not preserve the subquery order
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].ID < msgs[j].ID })
+	return msgs, nil
+}
+
+func (r *PostgresOutboxRepository) MarkDispatched(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE outbox SET dispatched_at = NOW(), locked_until = NULL WHERE id = $1`, id,
+	)
+	return err
+}
+
+func (r *PostgresOutboxRepository) MarkFailed(ctx context.Context, id int64, reason string) error {
+	// SET expressions see the row as it was, so attempts is the old count
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE outbox
+		SET attempts = attempts + 1, locked_until = NULL, last_error = $2,
+			parked_at = CASE WHEN attempts + 1 >= max_attempts THEN NOW() END
+		WHERE id = $1
+	`, id, reason)
+	return err
+}
+
+func (r *PostgresOutboxRepository) Park(ctx context.Context, id int64, reason string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE outbox SET locked_until = NULL, last_error = $2, parked_at = NOW() WHERE id = $1`, id, reason,
+	)
+	return err
+}
+
+func (r *PostgresOutboxRepository) Release(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE outbox SET locked_until = NULL WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresOutboxRepository) CountPending(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM outbox WHERE dispatched_at IS NULL AND parked_at IS NULL`,
+	).Scan(&count)
+	return count, err
+}
+
+// insertOutbox records events inside the caller's transaction.
+func insertOutbox(ctx context.Context, tx *sql.Tx, events []ports.OutboxMessage) error {
+	query := `
+		INSERT INTO outbox (aggregate_id, event_type, payload, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	for _, event := range events {
+		// JSONB needs the payload as text; []byte would be sent as bytea
+		if _, err := tx.ExecContext(ctx, query, event.AggregateID, event.EventType, string(event.Payload), event.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
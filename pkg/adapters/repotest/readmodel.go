@@ -0,0 +1,155 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+	"github.com/google/uuid"
+)
+
+// ReadModel is a read model adapter: the worker writes to it and list
+// queries read from it.
+type ReadModel interface {
+	ports.ReservationReadModelWriter
+	ports.ReservationReadModel
+}
+
+// ReadModelFactory returns a fresh, empty read model. It is called once
+// per subtest.
+type ReadModelFactory func(t *testing.T) ReadModel
+
+// RunReadModel checks that read model writes tolerate the duplicates and
+// reordering at-least-once delivery produces.
+func RunReadModel(t *testing.T, factory ReadModelFactory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, rm ReadModel)
+	}{
+		{"NewerVersionReplaces", testReadModelNewerReplaces},
+		{"StaleAndDuplicateWritesSkipped", testReadModelStaleSkipped},
+		{"MoveRemovesOldSlot", testReadModelMove},
+		{"LateCreateAfterMove", testReadModelLateCreateAfterMove},
+		{"LateMoveAfterCancel", testReadModelLateMoveAfterCancel},
+		{"SaveRemovesLastWrittenSlot", testReadModelSaveRemovesLastSlot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, factory(t))
+		})
+	}
+}
+
+// readModelItem is one reservation in an event of its own, so subtests
+// sharing a table do not see each other's items.
+type readModelItem struct {
+	eventID, id string
+}
+
+func newReadModelItem() readModelItem {
+	return readModelItem{eventID: uuid.New().String(), id: uuid.New().String()}
+}
+
+func (i readModelItem) at(start time.Time, status domain.ReservationStatus, version int) *domain.Reservation {
+	return &domain.Reservation{
+		ID:          i.id,
+		UserID:      "user-1",
+		EventID:     i.eventID,
+		StartTime:   start,
+		EndTime:     start.Add(time.Hour),
+		TicketCount: 1,
+		Status:      status,
+		UpdatedAt:   base,
+		Version:     version,
+	}
+}
+
+func testReadModelNewerReplaces(t *testing.T, rm ReadModel) {
+	item := newReadModelItem()
+	save(t, rm, item.at(base, domain.StatusBooked, 1))
+	save(t, rm, item.at(base, domain.StatusCheckedIn, 2))
+	assertListed(t, rm, item.eventID, item.at(base, domain.StatusCheckedIn, 2))
+}
+
+func testReadModelStaleSkipped(t *testing.T, rm ReadModel) {
+	item := newReadModelItem()
+	save(t, rm, item.at(base, domain.StatusCheckedIn, 2))
+	save(t, rm, item.at(base, domain.StatusBooked, 1))
+	save(t, rm, item.at(base, domain.StatusBooked, 2))
+	assertListed(t, rm, item.eventID, item.at(base, domain.StatusCheckedIn, 2))
+}
+
+func testReadModelMove(t *testing.T, rm ReadModel) {
+	item := newReadModelItem()
+	later := base.Add(24 * time.Hour)
+	save(t, rm, item.at(base, domain.StatusBooked, 1))
+	move(t, rm, item.at(later, domain.StatusBooked, 2), base)
+	assertListed(t, rm, item.eventID, item.at(later, domain.StatusBooked, 2))
+}
+
+func testReadModelLateCreateAfterMove(t *testing.T, rm ReadModel) {
+	item := newReadModelItem()
+	later := base.Add(24 * time.Hour)
+
+	// The move lands first; the creation must not bring back the old slot
+	move(t, rm, item.at(later, domain.StatusBooked, 2), base)
+	save(t, rm, item.at(base, domain.StatusBooked, 1))
+	assertListed(t, rm, item.eventID, item.at(later, domain.StatusBooked, 2))
+}
+
+func testReadModelLateMoveAfterCancel(t *testing.T, rm ReadModel) {
+	item := newReadModelItem()
+	later := base.Add(24 * time.Hour)
+
+	// The cancellation at the new slot overtakes the move; the old slot
+	// must not be left behind and the move must not undo the cancellation
+	save(t, rm, item.at(base, domain.StatusBooked, 1))
+	save(t, rm, item.at(later, domain.StatusCancelled, 3))
+	move(t, rm, item.at(later, domain.StatusBooked, 2), base)
+	assertListed(t, rm, item.eventID)
+}
+
+func testReadModelSaveRemovesLastSlot(t *testing.T, rm ReadModel) {
+	item := newReadModelItem()
+	later := base.Add(24 * time.Hour)
+
+	// The move was lost; the next change still clears the old slot
+	save(t, rm, item.at(base, domain.StatusBooked, 1))
+	save(t, rm, item.at(later, domain.StatusCheckedIn, 3))
+	assertListed(t, rm, item.eventID, item.at(later, domain.StatusCheckedIn, 3))
+}
+
+func save(t *testing.T, rm ReadModel, res *domain.Reservation) {
+	t.Helper()
+	if err := rm.SaveReadModel(context.Background(), res); err != nil {
+		t.Fatalf("SaveReadModel v%d: %v", res.Version, err)
+	}
+}
+
+func move(t *testing.T, rm ReadModel, res *domain.Reservation, oldStart time.Time) {
+	t.Helper()
+	if err := rm.MoveReadModel(context.Background(), res, oldStart); err != nil {
+		t.Fatalf("MoveReadModel v%d: %v", res.Version, err)
+	}
+}
+
+// assertListed checks what a listing of the whole event returns, which
+// leaves out cancelled reservations.
+func assertListed(t *testing.T, rm ReadModel, eventID string, want ...*domain.Reservation) {
+	t.Helper()
+	page, err := rm.ListByEvent(context.Background(), eventID, base.Add(-time.Hour), base.Add(48*time.Hour), 10, "")
+	if err != nil {
+		t.Fatalf("ListByEvent: %v", err)
+	}
+	if len(page.Items) != len(want) {
+		t.Fatalf("listed %d items; want %d", len(page.Items), len(want))
+	}
+	for i, w := range want {
+		g := page.Items[i]
+		if g.ID != w.ID || !g.StartTime.Equal(w.StartTime) || g.Status != w.Status || g.Version != w.Version {
+			t.Errorf("item %d = %s at %s, %s v%d; want %s at %s, %s v%d", i, g.ID, g.StartTime, g.Status, g.Version, w.ID, w.StartTime, w.Status, w.Version)
+		}
+	}
+}
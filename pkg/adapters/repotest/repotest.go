@@ -0,0 +1,719 @@
+// Package repotest is the conformance suite every ports.ReservationRepository
+// implementation must pass, so adapters can be swapped without callers
+// noticing. Each adapter runs it from its own tests:
+//
+//	repotest.Run(t, func(t *testing.T) repotest.Fixture { ... })
+package repotest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+	"github.com/google/uuid"
+)
+
+// Fixture is a set of adapters sharing one empty store. Events is used to
+// set up the catalog the capacity rules read from. Outbox is optional;
+// when set, the suite also checks that outbox messages are written in the
+// same transaction as the reservation.
+type Fixture struct {
+	Reservations ports.ReservationRepository
+	Events       ports.EventRepository
+	Outbox       ports.OutboxRepository
+}
+
+// Factory returns a fresh, empty fixture. It is called once per subtest.
+type Factory func(t *testing.T) Fixture
+
+// base is whole seconds in UTC so every adapter can round-trip it exactly.
+var base = time.Date(2030, 6, 1, 18, 0, 0, 0, time.UTC)
+
+// Run exercises every ReservationRepository method against the adapters
+// returned by factory.
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, f Fixture)
+	}{
+		{"GetByIDMissReturnsNil", testGetByIDMiss},
+		{"SaveRoundTrip", testSaveRoundTrip},
+		{"SaveDuplicateID", testSaveDuplicateID},
+		{"SaveUnknownEvent", testSaveUnknownEvent},
+		{"SaveEventCapacity", testSaveEventCapacity},
+		{"SaveSlotCapacity", testSaveSlotCapacity},
+		{"CancelledDoNotCountAgainstCapacity", testCancelledFreeCapacity},
+		{"UpdateBumpsVersion", testUpdateBumpsVersion},
+		{"UpdateStaleVersion", testUpdateStaleVersion},
+		{"UpdateMissing", testUpdateMissing},
+		{"UpdateExcludesOwnTickets", testUpdateExcludesOwnTickets},
+		{"UpdateCapacityExceeded", testUpdateCapacityExceeded},
+		{"StatusChangesSkipCapacity", testStatusChangesSkipCapacity},
+		{"GetByEventAndRange", testGetByEventAndRange},
+		{"GetByEventAndRangeEmpty", testGetByEventAndRangeEmpty},
+		{"HoldRoundTrip", testHoldRoundTrip},
+		{"LapsedHoldsDoNotCountAgainstCapacity", testLapsedHoldsFreeCapacity},
+		{"ListExpiredHolds", testListExpiredHolds},
+		{"TransitionsRecorded", testTransitionsRecorded},
+		{"OutboxWrittenWithReservation", testOutboxAtomic},
+		{"OutboxParking", testOutboxParking},
+		{"ConcurrentSavesRespectCapacity", testConcurrentSaves},
+		{"ConcurrentUpdatesConflict", testConcurrentUpdates},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, factory(t))
+		})
+	}
+}
+
+func testGetByIDMiss(t *testing.T, f Fixture) {
+	res, err := f.Reservations.GetByID(context.Background(), uuid.New().String())
+	if err != nil || res != nil {
+		t.Fatalf("GetByID(unknown) = %v, %v; want nil, nil", res, err)
+	}
+}
+
+func testSaveRoundTrip(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	event := newEvent(t, f, 0, 0)
+	want := newReservation(event.ID, base, 3)
+	mustSave(t, f, want)
+
+	got, err := f.Reservations.GetByID(ctx, want.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got == nil {
+		t.Fatal("GetByID returned nil for a saved reservation")
+	}
+	assertEqual(t, got, want)
+}
+
+func testSaveDuplicateID(t *testing.T, f Fixture) {
+	event := newEvent(t, f, 0, 0)
+	res := newReservation(event.ID, base, 1)
+	mustSave(t, f, res)
+
+	dup := newReservation(event.ID, base.Add(time.Hour), 1)
+	dup.ID = res.ID
+	if err := f.Reservations.Save(context.Background(), dup); err == nil {
+		t.Fatal("Save with an existing id succeeded")
+	}
+}
+
+func testSaveUnknownEvent(t *testing.T, f Fixture) {
+	res := newReservation(uuid.New().String(), base, 1)
+	err := f.Reservations.Save(context.Background(), res)
+	if !errors.Is(err, domain.ErrEventNotFound) {
+		t.Fatalf("Save for an unknown event = %v; want %v", err, domain.ErrEventNotFound)
+	}
+}
+
+func testSaveEventCapacity(t *testing.T, f Fixture) {
+	event := newEvent(t, f, 5, 0)
+	mustSave(t, f, newReservation(event.ID, base, 3))
+	mustSave(t, f, newReservation(event.ID, base.Add(time.Hour), 2))
+
+	err := f.Reservations.Save(context.Background(), newReservation(event.ID, base.Add(2*time.Hour), 1))
+	if !errors.Is(err, domain.ErrCapacityExceeded) {
+		t.Fatalf("Save over event capacity = %v; want %v", err, domain.ErrCapacityExceeded)
+	}
+}
+
+func testSaveSlotCapacity(t *testing.T, f Fixture) {
+	event := newEvent(t, f, 0, 2)
+	mustSave(t, f, newReservation(event.ID, base, 2))
+
+	err := f.Reservations.Save(context.Background(), newReservation(event.ID, base, 1))
+	if !errors.Is(err, domain.ErrCapacityExceeded) {
+		t.Fatalf("Save over slot capacity = %v; want %v", err, domain.ErrCapacityExceeded)
+	}
+
+	// Other slots are unaffected
+	mustSave(t, f, newReservation(event.ID, base.Add(time.Hour), 2))
+}
+
+func testCancelledFreeCapacity(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	event := newEvent(t, f, 2, 0)
+	res := newReservation(event.ID, base, 2)
+	mustSave(t, f, res)
+
+	res.Status = domain.StatusCancelled
+	if err := f.Reservations.Update(ctx, res); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	mustSave(t, f, newReservation(event.ID, base, 2))
+}
+
+func testUpdateBumpsVersion(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	event := newEvent(t, f, 0, 0)
+	res := newReservation(event.ID, base, 1)
+	mustSave(t, f, res)
+
+	res.StartTime = base.Add(24 * time.Hour)
+	res.EndTime = res.StartTime.Add(2 * time.Hour)
+	res.TicketCount = 4
+	res.UpdatedAt = base.Add(time.Minute)
+	if err := f.Reservations.Update(ctx, res); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if res.Version != 2 {
+		t.Fatalf("Version after Update = %d; want 2", res.Version)
+	}
+
+	got, err := f.Reservations.GetByID(ctx, res.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	assertEqual(t, got, res)
+}
+
+func testUpdateStaleVersion(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	event := newEvent(t, f, 0, 0)
+	res := newReservation(event.ID, base, 1)
+	mustSave(t, f, res)
+
+	stale := *res
+	if err := f.Reservations.Update(ctx, res); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	stale.TicketCount = 2
+	if err := f.Reservations.Update(ctx, &stale); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Fatalf("Update with a stale version = %v; want %v", err, domain.ErrVersionConflict)
+	}
+	if stale.Version != 1 {
+		t.Fatalf("failed Update changed Version to %d", stale.Version)
+	}
+}
+
+func testUpdateMissing(t *testing.T, f Fixture) {
+	event := newEvent(t, f, 0, 0)
+	res := newReservation(event.ID, base, 1)
+	if err := f.Reservations.Update(context.Background(), res); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Fatalf("Update of a missing reservation = %v; want %v", err, domain.ErrVersionConflict)
+	}
+}
+
+func testUpdateExcludesOwnTickets(t *testing.T, f Fixture) {
+	event := newEvent(t, f, 3, 3)
+	res := newReservation(event.ID, base, 3)
+	mustSave(t, f, res)
+
+	// The reservation's current tickets must not count against itself
+	res.StartTime = base.Add(time.Hour)
+	res.EndTime = res.StartTime.Add(2 * time.Hour)
+	res.UpdatedAt = base.Add(time.Minute)
+	if err := f.Reservations.Update(context.Background(), res); err != nil {
+		t.Fatalf("Update at full capacity: %v", err)
+	}
+}
+
+func testStatusChangesSkipCapacity(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	event := newEvent(t, f, 2, 0)
+	res := newReservation(event.ID, base, 2)
+	mustSave(t, f, res)
+
+	// Shrink the event below what is already booked
+	event.Capacity = 1
+	if err := f.Events.Update(ctx, event); err != nil {
+		t.Fatalf("update event: %v", err)
+	}
+
+	// Guests already in keep their seats through check-in and completion
+	for _, status := range []domain.ReservationStatus{domain.StatusCheckedIn, domain.StatusCompleted} {
+		res.Status = status
+		if err := f.Reservations.Update(ctx, res); err != nil {
+			t.Fatalf("Update to %s over capacity: %v", status, err)
+		}
+	}
+
+	// Taking more seats is still checked
+	res.TicketCount = 3
+	if err := f.Reservations.Update(ctx, res); !errors.Is(err, domain.ErrCapacityExceeded) {
+		t.Fatalf("Update to more tickets = %v; want %v", err, domain.ErrCapacityExceeded)
+	}
+	hold := newHold(event.ID, base.Add(time.Hour), 1, base.Add(time.Hour))
+	if err := f.Reservations.Save(ctx, hold); !errors.Is(err, domain.ErrCapacityExceeded) {
+		t.Fatalf("Save over capacity = %v; want %v", err, domain.ErrCapacityExceeded)
+	}
+}
+
+func testUpdateCapacityExceeded(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	event := newEvent(t, f, 4, 0)
+	mustSave(t, f, newReservation(event.ID, base, 2))
+	res := newReservation(event.ID, base.Add(time.Hour), 2)
+	mustSave(t, f, res)
+
+	res.TicketCount = 3
+	if err := f.Reservations.Update(ctx, res); !errors.Is(err, domain.ErrCapacityExceeded) {
+		t.Fatalf("Update over capacity = %v; want %v", err, domain.ErrCapacityExceeded)
+	}
+
+	got, err := f.Reservations.GetByID(ctx, res.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.TicketCount != 2 || got.Version != 1 {
+		t.Fatalf("rejected Update was applied: ticket_count=%d version=%d", got.TicketCount, got.Version)
+	}
+}
+
+func testGetByEventAndRange(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	event := newEvent(t, f, 0, 0)
+	other := newEvent(t, f, 0, 0)
+
+	// Saved out of order to check the sort
+	late := newReservation(event.ID, base.Add(3*time.Hour), 1)
+	early := newReservation(event.ID, base, 1)
+	middle := newReservation(event.ID, base.Add(time.Hour), 1)
+	cancelled := newReservation(event.ID, base.Add(2*time.Hour), 1)
+	atEnd := newReservation(event.ID, base.Add(4*time.Hour), 1)
+	beforeStart := newReservation(event.ID, base.Add(-time.Second), 1)
+	otherEvent := newReservation(other.ID, base.Add(time.Hour), 1)
+	for _, res := range []*domain.Reservation{late, early, middle, cancelled, atEnd, beforeStart, otherEvent} {
+		mustSave(t, f, res)
+	}
+	cancelled.Status = domain.StatusCancelled
+	if err := f.Reservations.Update(ctx, cancelled); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	got, err := f.Reservations.GetByEventAndRange(ctx, event.ID, base, base.Add(4*time.Hour))
+	if err != nil {
+		t.Fatalf("GetByEventAndRange: %v", err)
+	}
+
+	want := []*domain.Reservation{early, middle, late}
+	if len(got) != len(want) {
+		t.Fatalf("GetByEventAndRange returned %d reservations; want %d (start inclusive, end exclusive, cancelled and other events excluded)", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID {
+			t.Fatalf("GetByEventAndRange[%d] = %s; want %s (ordered by start time)", i, got[i].ID, want[i].ID)
+		}
+		assertEqual(t, got[i], want[i])
+	}
+}
+
+func testGetByEventAndRangeEmpty(t *testing.T, f Fixture) {
+	event := newEvent(t, f, 0, 0)
+	got, err := f.Reservations.GetByEventAndRange(context.Background(), event.ID, base, base.Add(time.Hour))
+	if err != nil || len(got) != 0 {
+		t.Fatalf("GetByEventAndRange on an empty event = %v, %v; want no reservations", got, err)
+	}
+}
+
+func testHoldRoundTrip(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	event := newEvent(t, f, 0, 0)
+	hold := newHold(event.ID, base, 2, base.Add(10*time.Minute))
+	mustSave(t, f, hold)
+
+	got, err := f.Reservations.GetByID(ctx, hold.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	assertEqual(t, got, hold)
+
+	// Confirming clears the expiry
+	hold.Status = domain.StatusBooked
+	hold.ExpiresAt = nil
+	if err := f.Reservations.Update(ctx, hold); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err = f.Reservations.GetByID(ctx, hold.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	assertEqual(t, got, hold)
+}
+
+func testLapsedHoldsFreeCapacity(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	event := newEvent(t, f, 2, 0)
+
+	// Lapsed is judged at the time of the change being saved, not by the
+	// store's clock: a hold lapsing at base no longer counts from base on
+	mustSave(t, f, newHold(event.ID, base, 2, base))
+	mustSave(t, f, newReservation(event.ID, base, 2))
+
+	live := newHold(event.ID, base, 1, base.Add(time.Hour))
+	if err := f.Reservations.Save(ctx, live); !errors.Is(err, domain.ErrCapacityExceeded) {
+		t.Fatalf("Save over capacity = %v; want %v", err, domain.ErrCapacityExceeded)
+	}
+
+	expired := newReservation(event.ID, base, 2)
+	expired.Status = domain.StatusExpired
+	mustSave(t, f, expired)
+
+	// Before its expiry the hold still counts
+	other := newEvent(t, f, 2, 0)
+	mustSave(t, f, newHold(other.ID, base, 2, base.Add(time.Minute)))
+	early := newReservation(other.ID, base, 1)
+	if err := f.Reservations.Save(ctx, early); !errors.Is(err, domain.ErrCapacityExceeded) {
+		t.Fatalf("Save before the hold lapsed = %v; want %v", err, domain.ErrCapacityExceeded)
+	}
+}
+
+func testListExpiredHolds(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	event := newEvent(t, f, 0, 0)
+	now := base.Add(-time.Hour)
+
+	later := newHold(event.ID, base, 1, now.Add(-time.Minute))
+	earlier := newHold(event.ID, base, 1, now.Add(-time.Hour))
+	atNow := newHold(event.ID, base, 1, now)
+	live := newHold(event.ID, base, 1, now.Add(time.Minute))
+	booked := newReservation(event.ID, base, 1)
+	for _, res := range []*domain.Reservation{later, earlier, atNow, live, booked} {
+		mustSave(t, f, res)
+	}
+
+	got, err := f.Reservations.ListExpiredHolds(ctx, now, 10)
+	if err != nil {
+		t.Fatalf("ListExpiredHolds: %v", err)
+	}
+	want := []*domain.Reservation{earlier, later, atNow}
+	if len(got) != len(want) {
+		t.Fatalf("ListExpiredHolds returned %d holds; want %d (expired at or before now, HELD only)", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID {
+			t.Fatalf("ListExpiredHolds[%d] = %s; want %s (earliest expiry first)", i, got[i].ID, want[i].ID)
+		}
+	}
+
+	got, err = f.Reservations.ListExpiredHolds(ctx, now, 1)
+	if err != nil {
+		t.Fatalf("ListExpiredHolds: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != earlier.ID {
+		t.Fatalf("ListExpiredHolds with limit 1 = %v; want only %s", got, earlier.ID)
+	}
+}
+
+func testTransitionsRecorded(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	event := newEvent(t, f, 0, 0)
+
+	res, err := domain.NewReservation("user-1", event.ID, base, base.Add(2*time.Hour), 1, base.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("NewReservation: %v", err)
+	}
+	res.ID = uuid.New().String()
+	mustSave(t, f, res)
+	if pending := res.PendingTransitions(); len(pending) != 0 {
+		t.Fatalf("pending after Save = %v; want none", pending)
+	}
+
+	// A rejected update records nothing
+	stale := *res
+	stale.Version = 99
+	if err := stale.CheckIn("staff-1", base); err != nil {
+		t.Fatalf("CheckIn: %v", err)
+	}
+	if err := f.Reservations.Update(ctx, &stale); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Fatalf("Update with a stale version = %v; want %v", err, domain.ErrVersionConflict)
+	}
+
+	if err := res.CheckIn("staff-1", base.Add(time.Minute)); err != nil {
+		t.Fatalf("CheckIn: %v", err)
+	}
+	if err := f.Reservations.Update(ctx, res); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	got, err := f.Reservations.ListTransitions(ctx, res.ID)
+	if err != nil {
+		t.Fatalf("ListTransitions: %v", err)
+	}
+	want := []domain.Transition{
+		{ReservationID: res.ID, To: domain.StatusBooked, Actor: "user-1", At: base.Add(-time.Hour)},
+		{ReservationID: res.ID, From: domain.StatusBooked, To: domain.StatusCheckedIn, Actor: "staff-1", At: base.Add(time.Minute)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ListTransitions = %+v; want %+v", got, want)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.ReservationID != w.ReservationID || g.From != w.From || g.To != w.To || g.Actor != w.Actor || g.Reason != w.Reason || !g.At.Equal(w.At) {
+			t.Fatalf("transition %d = %+v; want %+v", i, g, w)
+		}
+	}
+
+	if got, err := f.Reservations.ListTransitions(ctx, uuid.New().String()); err != nil || len(got) != 0 {
+		t.Fatalf("ListTransitions for an unknown id = %v, %v; want none", got, err)
+	}
+}
+
+func testOutboxAtomic(t *testing.T, f Fixture) {
+	if f.Outbox == nil {
+		t.Skip("fixture has no outbox")
+	}
+	ctx := context.Background()
+	event := newEvent(t, f, 1, 0)
+
+	res := newReservation(event.ID, base, 1)
+	if err := f.Reservations.Save(ctx, res, outboxMessage(res)); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	assertPending(t, f, 1)
+
+	// A rejected write must not leave its event behind
+	rejected := newReservation(event.ID, base, 1)
+	if err := f.Reservations.Save(ctx, rejected, outboxMessage(rejected)); !errors.Is(err, domain.ErrCapacityExceeded) {
+		t.Fatalf("Save over capacity = %v; want %v", err, domain.ErrCapacityExceeded)
+	}
+	stale := *res
+	stale.Version = 99
+	if err := f.Reservations.Update(ctx, &stale, outboxMessage(&stale)); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Fatalf("Update with a stale version = %v; want %v", err, domain.ErrVersionConflict)
+	}
+	assertPending(t, f, 1)
+
+	if err := f.Reservations.Update(ctx, res, outboxMessage(res), outboxMessage(res)); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	assertPending(t, f, 3)
+}
+
+func testOutboxParking(t *testing.T, f Fixture) {
+	if f.Outbox == nil {
+		t.Skip("fixture has no outbox")
+	}
+	ctx := context.Background()
+	event := newEvent(t, f, 1, 0)
+
+	res := newReservation(event.ID, base, 1)
+	if err := f.Reservations.Save(ctx, res, outboxMessage(res), outboxMessage(res), outboxMessage(res)); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	msgs, err := f.Outbox.ClaimPending(ctx, 10, time.Minute)
+	if err != nil || len(msgs) != 3 {
+		t.Fatalf("ClaimPending = %d messages, %v; want 3", len(msgs), err)
+	}
+	poison, failing, released := msgs[0], msgs[1], msgs[2]
+
+	if err := f.Outbox.Park(ctx, poison.ID, "bad payload"); err != nil {
+		t.Fatalf("Park: %v", err)
+	}
+	if err := f.Outbox.Release(ctx, released.ID); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	// Fail one short of the limit: it stays pending with its attempts counted
+	for i := 1; i < ports.OutboxMaxAttempts; i++ {
+		if err := f.Outbox.MarkFailed(ctx, failing.ID, "broker down"); err != nil {
+			t.Fatalf("MarkFailed: %v", err)
+		}
+	}
+	assertPending(t, f, 2)
+
+	msgs, err = f.Outbox.ClaimPending(ctx, 10, time.Minute)
+	if err != nil || len(msgs) != 2 || msgs[0].ID != failing.ID || msgs[1].ID != released.ID {
+		t.Fatalf("ClaimPending = %+v, %v; want messages %d and %d", msgs, err, failing.ID, released.ID)
+	}
+	if msgs[0].Attempts != ports.OutboxMaxAttempts-1 || msgs[1].Attempts != 0 {
+		t.Fatalf("attempts = %d, %d; want %d, 0", msgs[0].Attempts, msgs[1].Attempts, ports.OutboxMaxAttempts-1)
+	}
+
+	// The last allowed failure parks it too
+	if err := f.Outbox.MarkFailed(ctx, failing.ID, "broker down"); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+	if err := f.Outbox.Release(ctx, released.ID); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	assertPending(t, f, 1)
+	msgs, err = f.Outbox.ClaimPending(ctx, 10, time.Minute)
+	if err != nil || len(msgs) != 1 || msgs[0].ID != released.ID {
+		t.Fatalf("ClaimPending = %+v, %v; want only message %d", msgs, err, released.ID)
+	}
+}
+
+func testConcurrentSaves(t *testing.T, f Fixture) {
+	const capacity, attempts = 5, 20
+	event := newEvent(t, f, capacity, 0)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- f.Reservations.Save(context.Background(), newReservation(event.ID, base, 1))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	booked := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			booked++
+		case errors.Is(err, domain.ErrCapacityExceeded):
+		default:
+			t.Errorf("concurrent Save: %v", err)
+		}
+	}
+	if booked != capacity {
+		t.Fatalf("%d concurrent bookings succeeded; want exactly %d", booked, capacity)
+	}
+
+	got, err := f.Reservations.GetByEventAndRange(context.Background(), event.ID, base, base.Add(time.Second))
+	if err != nil {
+		t.Fatalf("GetByEventAndRange: %v", err)
+	}
+	if len(got) != capacity {
+		t.Fatalf("%d reservations stored; want %d", len(got), capacity)
+	}
+}
+
+func testConcurrentUpdates(t *testing.T, f Fixture) {
+	const writers = 10
+	event := newEvent(t, f, 0, 0)
+	res := newReservation(event.ID, base, 1)
+	mustSave(t, f, res)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(tickets int) {
+			defer wg.Done()
+			change := *res
+			change.TicketCount = tickets
+			errs <- f.Reservations.Update(context.Background(), &change)
+		}(i + 1)
+	}
+	wg.Wait()
+	close(errs)
+
+	applied := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			applied++
+		case errors.Is(err, domain.ErrVersionConflict):
+		default:
+			t.Errorf("concurrent Update: %v", err)
+		}
+	}
+	if applied != 1 {
+		t.Fatalf("%d updates of the same version succeeded; want exactly 1", applied)
+	}
+
+	got, err := f.Reservations.GetByID(context.Background(), res.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Version != 2 {
+		t.Fatalf("Version after concurrent updates = %d; want 2", got.Version)
+	}
+}
+
+func newEvent(t *testing.T, f Fixture, capacity, slotCapacity int) *domain.Event {
+	t.Helper()
+	event := &domain.Event{
+		ID:           uuid.New().String(),
+		Name:         "Contract Test " + uuid.New().String()[:8],
+		Venue:        "Test Hall",
+		Timezone:     "UTC",
+		Capacity:     capacity,
+		SlotCapacity: slotCapacity,
+		CreatedAt:    base,
+		UpdatedAt:    base,
+	}
+	if err := f.Events.Save(context.Background(), event); err != nil {
+		t.Fatalf("save event: %v", err)
+	}
+	return event
+}
+
+func newReservation(eventID string, start time.Time, tickets int) *domain.Reservation {
+	return &domain.Reservation{
+		ID:          uuid.New().String(),
+		UserID:      "user-" + uuid.New().String()[:8],
+		EventID:     eventID,
+		StartTime:   start,
+		EndTime:     start.Add(2 * time.Hour),
+		TicketCount: tickets,
+		Status:      domain.StatusBooked,
+		CreatedAt:   base,
+		UpdatedAt:   base,
+		Version:     1,
+	}
+}
+
+func newHold(eventID string, start time.Time, tickets int, expiresAt time.Time) *domain.Reservation {
+	res := newReservation(eventID, start, tickets)
+	res.Status = domain.StatusHeld
+	res.ExpiresAt = &expiresAt
+	return res
+}
+
+func outboxMessage(res *domain.Reservation) ports.OutboxMessage {
+	return ports.OutboxMessage{
+		AggregateID: res.ID,
+		EventType:   domain.EventReservationCreated,
+		Payload:     []byte(`{"reservation_id":"` + res.ID + `"}`),
+		CreatedAt:   base,
+	}
+}
+
+func mustSave(t *testing.T, f Fixture, res *domain.Reservation) {
+	t.Helper()
+	if err := f.Reservations.Save(context.Background(), res); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+}
+
+func assertPending(t *testing.T, f Fixture, want int64) {
+	t.Helper()
+	got, err := f.Outbox.CountPending(context.Background())
+	if err != nil {
+		t.Fatalf("CountPending: %v", err)
+	}
+	if got != want {
+		t.Fatalf("CountPending = %d; want %d", got, want)
+	}
+}
+
+// assertEqual compares the stored fields; times are compared as instants
+// since adapters may return them in another location.
+func assertEqual(t *testing.T, got, want *domain.Reservation) {
+	t.Helper()
+	if got.ID != want.ID || got.UserID != want.UserID || got.EventID != want.EventID ||
+		got.TicketCount != want.TicketCount || got.Status != want.Status || got.Version != want.Version {
+		t.Fatalf("reservation = %+v; want %+v", got, want)
+	}
+	if (got.ExpiresAt == nil) != (want.ExpiresAt == nil) ||
+		got.ExpiresAt != nil && !got.ExpiresAt.Equal(*want.ExpiresAt) {
+		t.Fatalf("ExpiresAt = %v; want %v", got.ExpiresAt, want.ExpiresAt)
+	}
+	for _, tm := range []struct {
+		name      string
+		got, want time.Time
+	}{
+		{"StartTime", got.StartTime, want.StartTime},
+		{"EndTime", got.EndTime, want.EndTime},
+		{"CreatedAt", got.CreatedAt, want.CreatedAt},
+		{"UpdatedAt", got.UpdatedAt, want.UpdatedAt},
+	} {
+		if !tm.got.Equal(tm.want) {
+			t.Fatalf("%s = %s; want %s", tm.name, tm.got, tm.want)
+		}
+	}
+}
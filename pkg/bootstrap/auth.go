@@ -0,0 +1,234 @@
+package bootstrap
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/handlers"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/config"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Authenticator validates HS256 and RS256 bearer tokens.
+type Authenticator struct {
+	hmacSecret []byte
+	rsaKeys    map[string]*rsa.PublicKey // Keyed by kid; "" holds a standalone PEM key
+	staffRole  string
+	parser     *jwt.Parser
+}
+
+// Principal is who a valid token speaks for.
+type Principal struct {
+	Subject string
+	Staff   bool // The token carries the configured staff role or scope
+}
+
+// NewAuthenticator loads the configured HS256 secret, RS256 PEM key and
+// JWKS file. It returns nil when no key is configured, which leaves
+// authentication disabled.
+func NewAuthenticator(cfg config.AuthConfig) (*Authenticator, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+
+	a := &Authenticator{rsaKeys: map[string]*rsa.PublicKey{}, staffRole: cfg.StaffRole}
+
+	if cfg.HS256Secret != "" {
+		a.hmacSecret = []byte(cfg.HS256Secret)
+	}
+
+	if path := cfg.RS256PublicKeyFile; path != "" {
+		pemBytes, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		a.rsaKeys[""] = key
+	}
+
+	if path := cfg.JWKSFile; path != "" {
+		if err := a.loadJWKS(path); err != nil {
+			return nil, fmt.Errorf("load JWKS %s: %w", path, err)
+		}
+	}
+
+	var methods []string
+	if a.hmacSecret != nil {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if len(a.rsaKeys) > 0 {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	opts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+
+	return a, nil
+}
+
+// Middleware rejects requests without a valid bearer token and stores the
+// token subject, and whether it is staff, on the request context. A nil
+// Authenticator lets every request through.
+func (a *Authenticator) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	if a == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || raw == "" {
+			unauthorized(w, r, "Missing bearer token")
+			return
+		}
+
+		principal, err := a.Authenticate(raw)
+		if err != nil {
+			log.Printf("Rejected token [%s]: %v", r.Header.Get(handlers.CorrelationIDHeader), err)
+			unauthorized(w, r, "Invalid bearer token")
+			return
+		}
+
+		// The subject is also the actor recorded for status changes
+		ctx := domain.WithSubject(r.Context(), principal.Subject)
+		if principal.Staff {
+			ctx = domain.WithStaff(ctx)
+		}
+		next(w, r.WithContext(domain.WithActor(ctx, principal.Subject)))
+	}
+}
+
+// RequireStaff refuses requests whose token lacks the staff role. It runs
+// after Middleware; a nil Authenticator lets every request through.
+func (a *Authenticator) RequireStaff(next http.HandlerFunc) http.HandlerFunc {
+	if a == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !domain.IsStaff(r.Context()) {
+			handlers.WriteErrorResponse(w, r, http.StatusForbidden, handlers.CodeForbidden, "Staff role required", nil)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Authenticate validates a raw JWT and returns who it speaks for.
+func (a *Authenticator) Authenticate(raw string) (Principal, error) {
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(raw, claims, a.key); err != nil {
+		return Principal{}, err
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil {
+		return Principal{}, err
+	}
+	if subject == "" {
+		return Principal{}, errors.New("token has no subject")
+	}
+	return Principal{Subject: subject, Staff: a.hasStaffRole(claims)}, nil
+}
+
+// hasStaffRole looks for the staff role in the roles claim, a list, and
+// in the scope claim, a space-separated string as in OAuth 2.
+func (a *Authenticator) hasStaffRole(claims jwt.MapClaims) bool {
+	if a.staffRole == "" {
+		return false
+	}
+	if roles, ok := claims["roles"].([]interface{}); ok {
+		for _, role := range roles {
+			if role == a.staffRole {
+				return true
+			}
+		}
+	}
+	if scope, ok := claims["scope"].(string); ok {
+		for _, s := range strings.Fields(scope) {
+			if s == a.staffRole {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *Authenticator) key(token *jwt.Token) (interface{}, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return a.hmacSecret, nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := a.rsaKeys[kid]; ok {
+			return key, nil
+		}
+		// Tokens without a matching kid fall back to the standalone key
+		if key, ok := a.rsaKeys[""]; ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+}
+
+// loadJWKS reads the RSA keys of a JSON Web Key Set file.
+func (a *Authenticator) loadJWKS(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return err
+	}
+
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return fmt.Errorf("key %q: modulus: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return fmt.Errorf("key %q: exponent: %w", k.Kid, err)
+		}
+		a.rsaKeys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return nil
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, message string) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	handlers.WriteErrorResponse(w, r, http.StatusUnauthorized, handlers.CodeUnauthorized, message, nil)
+}
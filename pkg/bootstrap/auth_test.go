@@ -0,0 +1,149 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/config"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret"
+
+func signedToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return raw
+}
+
+func TestRequireStaff(t *testing.T) {
+	auth, err := NewAuthenticator(config.AuthConfig{HS256Secret: testSecret, StaffRole: "staff"})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+
+	var seenStaff bool
+	handler := auth.Middleware(auth.RequireStaff(func(w http.ResponseWriter, r *http.Request) {
+		seenStaff = domain.IsStaff(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   int
+	}{
+		{"ordinary user", jwt.MapClaims{"sub": "user-1"}, http.StatusForbidden},
+		{"other roles", jwt.MapClaims{"sub": "user-1", "roles": []string{"guest"}, "scope": "reservations:read"}, http.StatusForbidden},
+		{"staff role", jwt.MapClaims{"sub": "staff-1", "roles": []string{"guest", "staff"}}, http.StatusOK},
+		{"staff scope", jwt.MapClaims{"sub": "staff-1", "scope": "reservations:read staff"}, http.StatusOK},
+		{"no token", nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seenStaff = false
+			r := httptest.NewRequest(http.MethodPost, "/reservations/res-1/check-in", nil)
+			if tt.claims != nil {
+				r.Header.Set("Authorization", "Bearer "+signedToken(t, tt.claims))
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d; want %d (body %s)", w.Code, tt.want, w.Body)
+			}
+			if tt.want == http.StatusOK && !seenStaff {
+				t.Error("handler ran without the staff mark on the context")
+			}
+		})
+	}
+}
+
+func TestStaffRoutesRefuseOrdinaryUsers(t *testing.T) {
+	handler := NewHandler(&config.Config{
+		Env:             config.EnvDevelopment,
+		Storage:         config.StorageMemory,
+		ReadModelSource: config.ReadModelPostgres,
+		HoldTTL:         10 * time.Minute,
+		Auth:            config.AuthConfig{HS256Secret: testSecret, StaffRole: "staff"},
+	})
+	t.Cleanup(func() { Close() })
+
+	user := "Bearer " + signedToken(t, jwt.MapClaims{"sub": "user-1"})
+	staff := "Bearer " + signedToken(t, jwt.MapClaims{"sub": "staff-1", "roles": []string{"staff"}})
+	send := func(method, path, token, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Authorization", token)
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	start := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	w := send(http.MethodPost, "/reservations", user, `{"event_id":"event-1","start_time":"`+
+		start.Format(time.RFC3339)+`","end_time":"`+start.Add(time.Hour).Format(time.RFC3339)+`","ticket_count":1}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create = %d %s; want %d", w.Code, w.Body, http.StatusCreated)
+	}
+	var res domain.Reservation
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decode reservation: %v", err)
+	}
+
+	// Guests only see their own reservations in an event's list
+	list := "/reservations?event_id=event-1&start_date=" + start.Add(-time.Hour).Format(time.RFC3339) +
+		"&end_date=" + start.Add(2*time.Hour).Format(time.RFC3339)
+	other := "Bearer " + signedToken(t, jwt.MapClaims{"sub": "user-2"})
+	for _, tt := range []struct {
+		name, token string
+		want        int
+	}{
+		{"owner", user, 1},
+		{"other guest", other, 0},
+		{"staff", staff, 1},
+	} {
+		w := send(http.MethodGet, list, tt.token, "")
+		var items []domain.Reservation
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			t.Fatalf("list by %s = %d %s: %v", tt.name, w.Code, w.Body, err)
+		}
+		if len(items) != tt.want {
+			t.Errorf("list by %s returned %d reservations; want %d", tt.name, len(items), tt.want)
+		}
+	}
+
+	// The catalog is changed by staff only
+	for _, req := range []struct{ method, path string }{
+		{http.MethodPost, "/events"},
+		{http.MethodPut, "/events/event-1"},
+		{http.MethodDelete, "/api/events/event-1"},
+	} {
+		if w := send(req.method, req.path, user, `{"name":"Gala"}`); w.Code != http.StatusForbidden {
+			t.Errorf("%s %s by a guest = %d %s; want %d", req.method, req.path, w.Code, w.Body, http.StatusForbidden)
+		}
+	}
+
+	for _, action := range []string{"check-in", "no-show", "complete"} {
+		if w := send(http.MethodPost, "/api/reservations/"+res.ID+"/"+action, user, ""); w.Code != http.StatusForbidden {
+			t.Errorf("%s by the guest = %d %s; want %d", action, w.Code, w.Body, http.StatusForbidden)
+		}
+	}
+
+	// Staff may act on reservations of any guest
+	if w := send(http.MethodPost, "/reservations/"+res.ID+"/check-in", staff, ""); w.Code != http.StatusOK {
+		t.Fatalf("check-in by staff = %d %s; want %d", w.Code, w.Body, http.StatusOK)
+	}
+	if w := send(http.MethodPost, "/reservations/"+res.ID+"/complete", staff, ""); w.Code != http.StatusOK {
+		t.Fatalf("complete by staff = %d %s; want %d", w.Code, w.Body, http.StatusOK)
+	}
+}
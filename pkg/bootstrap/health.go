@@ -0,0 +1,115 @@
+package bootstrap
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/femisowemimo/booking-appointment/backend/migrations"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/handlers"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/messaging"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/migrate"
+)
+
+// outboxBacklogThreshold is the number of undispatched events above which
+// the relay is considered to be falling behind.
+const outboxBacklogThreshold = 1000
+
+var errNotConfigured = errors.New("not configured")
+
+func PostgresCheck(db *sql.DB) handlers.HealthCheck {
+	return handlers.HealthCheck{
+		Name:     "postgres",
+		Required: true,
+		Check: func(ctx context.Context) (string, error) {
+			if db == nil {
+				return "", errNotConfigured
+			}
+			return "", db.PingContext(ctx)
+		},
+	}
+}
+
+// MigrationCheck fails while the schema is behind the migrations embedded
+// in this build, since queries may reference columns that don't exist yet.
+func MigrationCheck(db *sql.DB) handlers.HealthCheck {
+	return handlers.HealthCheck{
+		Name:     "migrations",
+		Required: true,
+		Check: func(ctx context.Context) (string, error) {
+			if db == nil {
+				return "", errNotConfigured
+			}
+			migrator, err := migrate.New(db, migrations.FS)
+			if err != nil {
+				return "", err
+			}
+			current, err := migrator.CurrentVersion(ctx)
+			if err != nil {
+				return "", err
+			}
+			detail := fmt.Sprintf("version %d of %d", current, migrator.LatestVersion())
+			if current < migrator.LatestVersion() {
+				return detail, errors.New("schema is behind; run `migrate up`")
+			}
+			return detail, nil
+		},
+	}
+}
+
+// OutboxBacklogCheck reports the number of events waiting to be relayed.
+// A large backlog degrades the instance but does not take it out of
+// rotation: writes still succeed, only the read model lags.
+func OutboxBacklogCheck(outbox ports.OutboxRepository) handlers.HealthCheck {
+	return handlers.HealthCheck{
+		Name: "outbox",
+		Check: func(ctx context.Context) (string, error) {
+			if outbox == nil {
+				return "", errNotConfigured
+			}
+			pending, err := outbox.CountPending(ctx)
+			if err != nil {
+				return "", err
+			}
+			detail := fmt.Sprintf("%d pending", pending)
+			if pending > outboxBacklogThreshold {
+				return detail, fmt.Errorf("backlog above %d", outboxBacklogThreshold)
+			}
+			return detail, nil
+		},
+	}
+}
+
+func RabbitMQCheck(conn *messaging.Connection) handlers.HealthCheck {
+	return handlers.HealthCheck{
+		Name:     "rabbitmq",
+		Required: true,
+		Check: func(ctx context.Context) (string, error) {
+			if conn == nil || conn.IsClosed() {
+				return "", errors.New("connection closed")
+			}
+			return "", nil
+		},
+	}
+}
+
+func DynamoDBCheck(client *dynamodb.Client, table string) handlers.HealthCheck {
+	return handlers.HealthCheck{
+		Name:     "dynamodb",
+		Required: true,
+		Check: func(ctx context.Context) (string, error) {
+			if client == nil {
+				return "", errNotConfigured
+			}
+			out, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(table)})
+			if err != nil {
+				return "", err
+			}
+			return string(out.Table.TableStatus), nil
+		},
+	}
+}
@@ -0,0 +1,40 @@
+package domain
+
+import "context"
+
+type subjectKey struct{}
+
+// WithSubject stores the authenticated user id on the request context.
+// Services use it to keep users to their own reservations.
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// SubjectFromContext returns the authenticated user id, or "" when the
+// request was not authenticated.
+func SubjectFromContext(ctx context.Context) string {
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	return subject
+}
+
+type staffKey struct{}
+
+// WithStaff marks the authenticated user as staff, who run events rather
+// than attend them.
+func WithStaff(ctx context.Context) context.Context {
+	return context.WithValue(ctx, staffKey{}, true)
+}
+
+// IsStaff reports whether WithStaff marked the request.
+func IsStaff(ctx context.Context) bool {
+	staff, _ := ctx.Value(staffKey{}).(bool)
+	return staff
+}
+
+// AccessibleTo reports whether the authenticated user in ctx may read or
+// change r. Unauthenticated callers, i.e. local development and the
+// worker, and staff may access every reservation.
+func (r *Reservation) AccessibleTo(ctx context.Context) bool {
+	subject := SubjectFromContext(ctx)
+	return subject == "" || subject == r.UserID || IsStaff(ctx)
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrEventNotFound    = errors.New("event not found")
+	ErrInvalidEventName = errors.New("event name is required")
+	ErrInvalidTimezone  = errors.New("event timezone is not a valid IANA time zone")
+	ErrInvalidCapacity  = errors.New("event capacity must not be negative")
+)
+
+type Event struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Venue    string `json:"venue"`
+	Timezone string `json:"timezone"`
+	// Capacity caps the tickets sold across the whole event and SlotCapacity
+	// caps the tickets per start time. Zero means unlimited.
+	Capacity     int       `json:"capacity"`
+	SlotCapacity int       `json:"slot_capacity"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func NewEvent(name, venue, timezone string, capacity, slotCapacity int, now time.Time) (*Event, error) {
+	e := &Event{
+		CreatedAt: now,
+	}
+	if err := e.Update(name, venue, timezone, capacity, slotCapacity, now); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Update validates and applies editable catalog fields.
+// An empty timezone defaults to UTC.
+func (e *Event) Update(name, venue, timezone string, capacity, slotCapacity int, now time.Time) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ErrInvalidEventName
+	}
+
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return ErrInvalidTimezone
+	}
+
+	if capacity < 0 || slotCapacity < 0 {
+		return ErrInvalidCapacity
+	}
+
+	e.Name = name
+	e.Venue = strings.TrimSpace(venue)
+	e.Timezone = timezone
+	e.Capacity = capacity
+	e.SlotCapacity = slotCapacity
+	e.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrUnknownEventType = errors.New("unknown event type")
+
+// Event types double as routing keys once converted, e.g.
+// ReservationCreated is published on reservation.created.
+const (
+	EventReservationCreated     = "ReservationCreated"
+	EventReservationCancelled   = "ReservationCancelled"
+	EventReservationModified    = "ReservationModified"
+	EventReservationCompleted   = "ReservationCompleted"
+	EventReservationConfirmed   = "ReservationConfirmed"
+	EventReservationHoldExpired = "ReservationHoldExpired"
+	EventReservationCheckedIn   = "ReservationCheckedIn"
+	EventReservationNoShow      = "ReservationNoShow"
+)
+
+// DomainEvent is a fact about a reservation that other services consume.
+type DomainEvent interface {
+	EventType() string
+	AggregateID() string
+}
+
+// ReservationSnapshot is the reservation state carried by every event, so
+// consumers can project it without calling back into the API.
+type ReservationSnapshot struct {
+	ReservationID string            `json:"reservation_id"`
+	EventID       string            `json:"event_id"`
+	UserID        string            `json:"user_id"`
+	StartTime     time.Time         `json:"start_time"`
+	EndTime       time.Time         `json:"end_time"`
+	TicketCount   int               `json:"ticket_count"`
+	Status        ReservationStatus `json:"status"`
+	// ExpiresAt is set while the reservation is a hold
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func NewReservationSnapshot(res *Reservation) ReservationSnapshot {
+	var expiresAt *time.Time
+	if res.ExpiresAt != nil {
+		t := res.ExpiresAt.UTC()
+		expiresAt = &t
+	}
+	return ReservationSnapshot{
+		ReservationID: res.ID,
+		EventID:       res.EventID,
+		UserID:        res.UserID,
+		StartTime:     res.StartTime.UTC(),
+		EndTime:       res.EndTime.UTC(),
+		TicketCount:   res.TicketCount,
+		Status:        res.Status,
+		ExpiresAt:     expiresAt,
+	}
+}
+
+func (s ReservationSnapshot) AggregateID() string { return s.ReservationID }
+
+type ReservationCreated struct {
+	ReservationSnapshot
+}
+
+type ReservationCancelled struct {
+	ReservationSnapshot
+}
+
+type ReservationModified struct {
+	ReservationSnapshot
+	// PreviousStartTime lets projections keyed by start time move the item
+	PreviousStartTime time.Time `json:"previous_start_time"`
+}
+
+type ReservationCompleted struct {
+	ReservationSnapshot
+}
+
+type ReservationConfirmed struct {
+	ReservationSnapshot
+}
+
+type ReservationHoldExpired struct {
+	ReservationSnapshot
+}
+
+type ReservationCheckedIn struct {
+	ReservationSnapshot
+}
+
+type ReservationNoShow struct {
+	ReservationSnapshot
+}
+
+func (ReservationCreated) EventType() string     { return EventReservationCreated }
+func (ReservationCancelled) EventType() string   { return EventReservationCancelled }
+func (ReservationModified) EventType() string    { return EventReservationModified }
+func (ReservationCompleted) EventType() string   { return EventReservationCompleted }
+func (ReservationConfirmed) EventType() string   { return EventReservationConfirmed }
+func (ReservationHoldExpired) EventType() string { return EventReservationHoldExpired }
+func (ReservationCheckedIn) EventType() string   { return EventReservationCheckedIn }
+func (ReservationNoShow) EventType() string      { return EventReservationNoShow }
+
+// Envelope is the wire format shared by all domain events. Version is the
+// aggregate version the event produced, so consumers can discard stale
+// or duplicate deliveries. SchemaVersion versions the shape of Data.
+type Envelope struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schema_version"`
+	AggregateID   string          `json:"aggregate_id"`
+	Version       int             `json:"version"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// NewEnvelope wraps event for publishing under the given id, taking the
+// correlation id of the request that caused it from ctx.
+func NewEnvelope(ctx context.Context, id string, event DomainEvent, version int, occurredAt time.Time) (Envelope, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{
+		ID:            id,
+		Type:          event.EventType(),
+		SchemaVersion: EventSchemaVersion,
+		AggregateID:   event.AggregateID(),
+		Version:       version,
+		OccurredAt:    occurredAt.UTC(),
+		CorrelationID: CorrelationIDFromContext(ctx),
+		Data:          data,
+	}, nil
+}
+
+// DecodeEvent unmarshals the envelope's data into the event type it names.
+// It returns ErrUnknownEventType for types this build does not know.
+// Unknown fields are rejected rather than dropped; upcast older envelopes
+// first.
+func (e Envelope) DecodeEvent() (DomainEvent, error) {
+	var event DomainEvent
+	switch e.Type {
+	case EventReservationCreated:
+		event = &ReservationCreated{}
+	case EventReservationCancelled:
+		event = &ReservationCancelled{}
+	case EventReservationModified:
+		event = &ReservationModified{}
+	case EventReservationCompleted:
+		event = &ReservationCompleted{}
+	case EventReservationConfirmed:
+		event = &ReservationConfirmed{}
+	case EventReservationHoldExpired:
+		event = &ReservationHoldExpired{}
+	case EventReservationCheckedIn:
+		event = &ReservationCheckedIn{}
+	case EventReservationNoShow:
+		event = &ReservationNoShow{}
+	default:
+		return nil, ErrUnknownEventType
+	}
+	dec := json.NewDecoder(bytes.NewReader(e.Data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+type correlationIDKey struct{}
+
+// WithCorrelationID stores the id of the request being served so events
+// raised while handling it can be traced back to it.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
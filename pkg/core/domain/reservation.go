@@ -7,17 +7,54 @@ import (
 
 type ReservationStatus string
 
+// Allowed moves between statuses are defined in state.go.
 const (
 	StatusBooked    ReservationStatus = "BOOKED"
 	StatusCancelled ReservationStatus = "CANCELLED"
 	StatusCompleted ReservationStatus = "COMPLETED"
+	// StatusHeld seats are taken for a short while during checkout and
+	// become StatusExpired unless confirmed in time.
+	StatusHeld    ReservationStatus = "HELD"
+	StatusExpired ReservationStatus = "EXPIRED"
+	// StatusCheckedIn guests arrived; StatusNoShow guests never did.
+	StatusCheckedIn ReservationStatus = "CHECKED_IN"
+	StatusNoShow    ReservationStatus = "NO_SHOW"
 )
 
+// Released reports whether reservations in status s gave their seats back.
+// They no longer count against capacity or appear in listings.
+func (s ReservationStatus) Released() bool {
+	return s == StatusCancelled || s == StatusExpired
+}
+
+// NeedsCapacityCheck reports whether storing r over stored, its persisted
+// state, can take seats that stored did not hold: it moves to another slot,
+// asks for more tickets or turns a hold into a booking. Status changes such
+// as check-in or cancellation never do.
+func (r *Reservation) NeedsCapacityCheck(stored *Reservation) bool {
+	if r.Status.Released() {
+		return false
+	}
+	return stored.Status.Released() ||
+		!r.StartTime.Equal(stored.StartTime) ||
+		r.TicketCount > stored.TicketCount ||
+		(stored.Status == StatusHeld && r.Status != StatusHeld)
+}
+
 var (
 	ErrInvalidTime        = errors.New("invalid reservation time")
 	ErrPastTime           = errors.New("cannot make reservation in the past")
 	ErrDuration           = errors.New("reservation duration must be positive")
 	ErrInvalidTicketCount = errors.New("ticket count must be between 1 and 6")
+	ErrNotFound           = errors.New("reservation not found")
+	ErrVersionConflict    = errors.New("reservation was modified by another request")
+	ErrAlreadyCancelled   = errors.New("reservation is already cancelled")
+	ErrCapacityExceeded   = errors.New("not enough tickets left for this event")
+	ErrHoldExpired        = errors.New("reservation hold has expired")
+	ErrHoldNotExpired     = errors.New("reservation hold has not expired yet")
+	ErrInvalidHoldTTL     = errors.New("hold duration must be positive")
+	ErrNotModifiable      = errors.New("reservation can no longer be modified")
+	ErrNotStarted         = errors.New("reservation has not started yet")
 )
 
 type Reservation struct {
@@ -30,40 +67,175 @@ type Reservation struct {
 	Status      ReservationStatus `json:"status"`
 	CreatedAt   time.Time         `json:"created_at"`
 	UpdatedAt   time.Time         `json:"updated_at"`
-	Version     int               `json:"version"` // Optimistic locking
+	// ExpiresAt is when a HELD reservation lapses; nil for other statuses.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Version   int        `json:"version"` // Optimistic locking
+
+	// pending holds transitions not yet persisted; see PendingTransitions
+	pending []Transition
 }
 
-func NewReservation(userID, eventID string, start, end time.Time, ticketCount int) (*Reservation, error) {
-	if start.After(end) {
-		return nil, ErrInvalidTime
+// ReservationChanges lists the fields a modification may touch.
+// Nil fields keep their current value.
+type ReservationChanges struct {
+	StartTime   *time.Time
+	EndTime     *time.Time
+	TicketCount *int
+}
+
+// NewReservation validates a booking made at now and returns it unsaved.
+// The creation is recorded as a transition into BOOKED by the user.
+func NewReservation(userID, eventID string, start, end time.Time, ticketCount int, now time.Time) (*Reservation, error) {
+	return newReservation(userID, eventID, start, end, ticketCount, StatusBooked, now)
+}
+
+// NewHold is NewReservation for a hold that lapses ttl after now unless
+// it is confirmed.
+func NewHold(userID, eventID string, start, end time.Time, ticketCount int, ttl time.Duration, now time.Time) (*Reservation, error) {
+	if ttl <= 0 {
+		return nil, ErrInvalidHoldTTL
 	}
-	if start.Before(time.Now()) {
-		return nil, ErrPastTime
+	r, err := newReservation(userID, eventID, start, end, ticketCount, StatusHeld, now)
+	if err != nil {
+		return nil, err
 	}
 
-	duration := end.Sub(start)
-	if duration <= 0 {
-		return nil, ErrDuration
-	}
+	expiresAt := now.Add(ttl)
+	r.ExpiresAt = &expiresAt
+	return r, nil
+}
 
-	if ticketCount < 1 || ticketCount > 6 {
-		return nil, ErrInvalidTicketCount
+func newReservation(userID, eventID string, start, end time.Time, ticketCount int, status ReservationStatus, now time.Time) (*Reservation, error) {
+	if err := validateBooking(start, end, ticketCount, now); err != nil {
+		return nil, err
 	}
 
-	return &Reservation{
+	r := &Reservation{
 		UserID:      userID,
 		EventID:     eventID,
 		StartTime:   start,
 		EndTime:     end,
 		TicketCount: ticketCount,
-		Status:      StatusBooked,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		CreatedAt:   now,
 		Version:     1,
-	}, nil
+	}
+	r.record(status, userID, "", now)
+	return r, nil
+}
+
+// HoldExpired reports whether r is a hold whose time ran out at now,
+// whether or not it has been marked EXPIRED yet.
+func (r *Reservation) HoldExpired(now time.Time) bool {
+	if r.Status == StatusExpired {
+		return true
+	}
+	return r.Status == StatusHeld && r.ExpiresAt != nil && !now.Before(*r.ExpiresAt)
+}
+
+// Confirm turns a live hold into a booking.
+func (r *Reservation) Confirm(actor string, now time.Time) error {
+	if r.HoldExpired(now) {
+		return ErrHoldExpired
+	}
+	if err := r.transition(StatusBooked, actor, "", now); err != nil {
+		return err
+	}
+	r.ExpiresAt = nil
+	return nil
+}
+
+// Expire marks a lapsed hold EXPIRED, releasing its seats. The system is
+// recorded as the actor.
+func (r *Reservation) Expire(now time.Time) error {
+	if r.Status == StatusHeld && !r.HoldExpired(now) {
+		return ErrHoldNotExpired
+	}
+	return r.transition(StatusExpired, ActorSystem, "hold expired", now)
+}
+
+// CheckIn records that the guest arrived for a booking.
+func (r *Reservation) CheckIn(actor string, now time.Time) error {
+	return r.transition(StatusCheckedIn, actor, "", now)
+}
+
+// Complete closes a checked-in reservation once it is over.
+func (r *Reservation) Complete(actor string, now time.Time) error {
+	return r.transition(StatusCompleted, actor, "", now)
+}
+
+// MarkNoShow records that the guest never arrived. It is refused before
+// the booking starts.
+func (r *Reservation) MarkNoShow(actor, reason string, now time.Time) error {
+	if r.Status == StatusBooked && now.Before(r.StartTime) {
+		return ErrNotStarted
+	}
+	return r.transition(StatusNoShow, actor, reason, now)
+}
+
+// Modify reschedules the reservation or changes its ticket count, applying
+// the same rules as NewReservation to the resulting booking. Only live
+// holds and bookings can be modified.
+func (r *Reservation) Modify(changes ReservationChanges, now time.Time) error {
+	if r.Status == StatusCancelled {
+		return ErrAlreadyCancelled
+	}
+	if r.HoldExpired(now) {
+		return ErrHoldExpired
+	}
+	if r.Status != StatusHeld && r.Status != StatusBooked {
+		return ErrNotModifiable
+	}
+
+	start, end, ticketCount := r.StartTime, r.EndTime, r.TicketCount
+	if changes.StartTime != nil {
+		start = *changes.StartTime
+	}
+	if changes.EndTime != nil {
+		end = *changes.EndTime
+	}
+	if changes.TicketCount != nil {
+		ticketCount = *changes.TicketCount
+	}
+
+	if err := validateBooking(start, end, ticketCount, now); err != nil {
+		return err
+	}
+
+	r.StartTime = start
+	r.EndTime = end
+	r.TicketCount = ticketCount
+	r.UpdatedAt = now
+	return nil
 }
 
-func (r *Reservation) Cancel() {
-	r.Status = StatusCancelled
-	r.UpdatedAt = time.Now()
+func validateBooking(start, end time.Time, ticketCount int, now time.Time) error {
+	if start.After(end) {
+		return ErrInvalidTime
+	}
+	if start.Before(now) {
+		return ErrPastTime
+	}
+
+	duration := end.Sub(start)
+	if duration <= 0 {
+		return ErrDuration
+	}
+
+	if ticketCount < 1 || ticketCount > 6 {
+		return ErrInvalidTicketCount
+	}
+	return nil
+}
+
+// Cancel releases a hold or booking. Cancelling twice or cancelling an
+// expired hold keep their dedicated errors; other statuses are past the
+// point of cancellation.
+func (r *Reservation) Cancel(actor, reason string, now time.Time) error {
+	if r.Status == StatusCancelled {
+		return ErrAlreadyCancelled
+	}
+	if r.Status == StatusExpired {
+		return ErrHoldExpired
+	}
+	return r.transition(StatusCancelled, actor, reason, now)
 }
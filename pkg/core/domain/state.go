@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// transitions lists the statuses each status may move to. Statuses missing
+// from the map are terminal.
+var transitions = map[ReservationStatus][]ReservationStatus{
+	StatusHeld:      {StatusBooked, StatusCancelled, StatusExpired},
+	StatusBooked:    {StatusCheckedIn, StatusCancelled, StatusNoShow},
+	StatusCheckedIn: {StatusCompleted},
+}
+
+// CanTransitionTo reports whether a reservation in status s may move to to.
+func (s ReservationStatus) CanTransitionTo(to ReservationStatus) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrInvalidTransition is returned when a reservation is asked to move to
+// a status the state machine does not allow from its current one.
+type ErrInvalidTransition struct {
+	From ReservationStatus
+	To   ReservationStatus
+}
+
+func (e *ErrInvalidTransition) Error() string {
+	return fmt.Sprintf("reservation cannot move from %s to %s", e.From, e.To)
+}
+
+// Transition records a status change: who made it, when and why. From is
+// empty for the transition that created the reservation.
+type Transition struct {
+	ReservationID string            `json:"reservation_id"`
+	From          ReservationStatus `json:"from,omitempty"`
+	To            ReservationStatus `json:"to"`
+	Actor         string            `json:"actor"`
+	Reason        string            `json:"reason,omitempty"`
+	At            time.Time         `json:"at"`
+}
+
+// Actors recorded for changes nobody asked for directly.
+const (
+	ActorSystem    = "system"
+	ActorAnonymous = "anonymous"
+)
+
+// transition moves r to status to if the state machine allows it and
+// records the change for the repository to persist.
+func (r *Reservation) transition(to ReservationStatus, actor, reason string, now time.Time) error {
+	if !r.Status.CanTransitionTo(to) {
+		return &ErrInvalidTransition{From: r.Status, To: to}
+	}
+	r.record(to, actor, reason, now)
+	return nil
+}
+
+func (r *Reservation) record(to ReservationStatus, actor, reason string, now time.Time) {
+	r.pending = append(r.pending, Transition{
+		From:   r.Status,
+		To:     to,
+		Actor:  actor,
+		Reason: reason,
+		At:     now,
+	})
+	r.Status = to
+	r.UpdatedAt = now
+}
+
+// PendingTransitions returns the transitions made since r was loaded or
+// last saved. Repositories store them with the reservation.
+func (r *Reservation) PendingTransitions() []Transition {
+	pending := make([]Transition, len(r.pending))
+	for i, t := range r.pending {
+		t.ReservationID = r.ID
+		pending[i] = t
+	}
+	return pending
+}
+
+// ClearPendingTransitions is called by repositories once the pending
+// transitions are stored.
+func (r *Reservation) ClearPendingTransitions() {
+	r.pending = nil
+}
+
+type actorKey struct{}
+
+// WithActor stores who is making the request so the transitions it causes
+// can be attributed to them.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored by WithActor, or ActorAnonymous
+// for unauthenticated requests.
+func ActorFromContext(ctx context.Context) string {
+	if actor, _ := ctx.Value(actorKey{}).(string); actor != "" {
+		return actor
+	}
+	return ActorAnonymous
+}
@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EventSchemaVersion is the envelope schema this build produces. Changing
+// the shape of an event means bumping it, adding the JSON Schemas for the
+// new version under schemas/ and registering an upcaster from the old one.
+const EventSchemaVersion = 2
+
+// ErrUnsupportedSchemaVersion is returned for events newer than this build;
+// they become readable once the consumer is upgraded.
+var ErrUnsupportedSchemaVersion = errors.New("event schema version is newer than supported")
+
+// upcaster converts an envelope from one schema version to the next.
+type upcaster func(Envelope) (Envelope, error)
+
+// upcasters is keyed by the version each step upgrades from.
+var upcasters = map[int]upcaster{
+	0: upcastLegacyPayload,
+	1: upcastVersion1,
+}
+
+// Upcast upgrades an envelope of any known schema version to
+// EventSchemaVersion, one step at a time.
+func Upcast(e Envelope) (Envelope, error) {
+	if e.SchemaVersion > EventSchemaVersion {
+		return Envelope{}, fmt.Errorf("%w: %d", ErrUnsupportedSchemaVersion, e.SchemaVersion)
+	}
+	for e.SchemaVersion < EventSchemaVersion {
+		step, ok := upcasters[e.SchemaVersion]
+		if !ok {
+			return Envelope{}, fmt.Errorf("no upcaster from schema version %d", e.SchemaVersion)
+		}
+		from := e.SchemaVersion
+
+		var err error
+		if e, err = step(e); err != nil {
+			return Envelope{}, fmt.Errorf("upcast from schema version %d: %w", from, err)
+		}
+		e.SchemaVersion = from + 1
+	}
+	return e, nil
+}
+
+// legacyPayload is schema version 0: the flat event published before
+// envelopes existed. It still turns up in old outbox rows, retry queues
+// and the dead-letter queue.
+type legacyPayload struct {
+	EventType         string `json:"event_type"`
+	ReservationID     string `json:"reservation_id"`
+	EventID           string `json:"event_id"`
+	UserID            string `json:"user_id"`
+	StartTime         string `json:"start_time"`
+	EndTime           string `json:"end_time"`
+	PreviousStartTime string `json:"previous_start_time"`
+	TicketCount       int    `json:"ticket_count"`
+	Status            string `json:"status"`
+	Version           int    `json:"version"`
+}
+
+// upcastLegacyPayload moves the flat fields into a typed event. The
+// envelope may be empty apart from Data when the message was a bare
+// legacy body.
+func upcastLegacyPayload(e Envelope) (Envelope, error) {
+	var legacy legacyPayload
+	if err := json.Unmarshal(e.Data, &legacy); err != nil {
+		return Envelope{}, err
+	}
+
+	// Outbox rows carry the type in their own column rather than the body
+	eventType := e.Type
+	if eventType == "" {
+		eventType = legacy.EventType
+	}
+
+	// The flat format only marked cancellations through the type
+	status := StatusBooked
+	if eventType == EventReservationCancelled {
+		status = StatusCancelled
+	} else if legacy.Status != "" {
+		status = ReservationStatus(legacy.Status)
+	}
+
+	start, err := time.Parse(time.RFC3339, legacy.StartTime)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("start_time: %v", err)
+	}
+	// Older events did not carry end_time
+	var end time.Time
+	if legacy.EndTime != "" {
+		if end, err = time.Parse(time.RFC3339, legacy.EndTime); err != nil {
+			return Envelope{}, fmt.Errorf("end_time: %v", err)
+		}
+	}
+
+	snapshot := ReservationSnapshot{
+		ReservationID: legacy.ReservationID,
+		EventID:       legacy.EventID,
+		UserID:        legacy.UserID,
+		StartTime:     start,
+		EndTime:       end,
+		TicketCount:   legacy.TicketCount,
+		Status:        status,
+	}
+
+	var event DomainEvent
+	switch eventType {
+	case EventReservationCancelled:
+		event = ReservationCancelled{ReservationSnapshot: snapshot}
+	case EventReservationModified:
+		modified := ReservationModified{ReservationSnapshot: snapshot}
+		if legacy.PreviousStartTime != "" {
+			if modified.PreviousStartTime, err = time.Parse(time.RFC3339, legacy.PreviousStartTime); err != nil {
+				return Envelope{}, fmt.Errorf("previous_start_time: %v", err)
+			}
+		}
+		event = modified
+	default:
+		event = ReservationCreated{ReservationSnapshot: snapshot}
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	e.Type = event.EventType()
+	e.AggregateID = legacy.ReservationID
+	e.Data = data
+	if e.Version == 0 {
+		e.Version = legacy.Version
+	}
+	return e, nil
+}
+
+// upcastVersion1 lifts schema version 1. Version 2 added holds (the HELD
+// and EXPIRED statuses, expires_at and the Confirmed and HoldExpired
+// events) and check-in (CHECKED_IN and NO_SHOW with their events).
+// Version 1 data is a subset of that, so it carries over as is.
+func upcastVersion1(e Envelope) (Envelope, error) {
+	return e, nil
+}
@@ -0,0 +1,168 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+)
+
+func TestUpcastLegacyPayloads(t *testing.T) {
+	start := time.Date(2030, 1, 10, 18, 0, 0, 0, time.UTC)
+	occurredAt := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		in          domain.Envelope
+		wantType    string
+		wantStatus  domain.ReservationStatus
+		wantVersion int
+		wantEnd     time.Time
+		wantPrev    time.Time
+	}{
+		{
+			name: "bare created body",
+			in: domain.Envelope{Data: []byte(`{"event_type":"ReservationCreated","reservation_id":"res-1","event_id":"event-1",` +
+				`"user_id":"user-1","start_time":"2030-01-10T18:00:00Z","end_time":"2030-01-10T20:00:00Z","ticket_count":2,"version":1}`)},
+			wantType:    domain.EventReservationCreated,
+			wantStatus:  domain.StatusBooked,
+			wantVersion: 1,
+			wantEnd:     start.Add(2 * time.Hour),
+		},
+		{
+			name: "bare cancelled body without end time",
+			in: domain.Envelope{Data: []byte(`{"event_type":"ReservationCancelled","reservation_id":"res-1","event_id":"event-1",` +
+				`"user_id":"user-1","start_time":"2030-01-10T18:00:00Z","ticket_count":2,"status":"BOOKED","version":2}`)},
+			wantType:    domain.EventReservationCancelled,
+			wantStatus:  domain.StatusCancelled,
+			wantVersion: 2,
+		},
+		{
+			name: "bare modified body",
+			in: domain.Envelope{Data: []byte(`{"event_type":"ReservationModified","reservation_id":"res-1","event_id":"event-1",` +
+				`"user_id":"user-1","start_time":"2030-01-10T18:00:00Z","previous_start_time":"2030-01-09T18:00:00Z","ticket_count":2,"version":3}`)},
+			wantType:    domain.EventReservationModified,
+			wantStatus:  domain.StatusBooked,
+			wantVersion: 3,
+			wantPrev:    start.Add(-24 * time.Hour),
+		},
+		{
+			// decodeOutboxMessage wraps pre-envelope outbox rows with the
+			// row's own metadata; the type comes from the row
+			name: "legacy outbox row",
+			in: domain.Envelope{
+				ID:          "42",
+				Type:        domain.EventReservationCancelled,
+				AggregateID: "res-1",
+				OccurredAt:  occurredAt,
+				Data: []byte(`{"reservation_id":"res-1","event_id":"event-1","user_id":"user-1",` +
+					`"start_time":"2030-01-10T18:00:00Z","ticket_count":1,"version":4}`),
+			},
+			wantType:    domain.EventReservationCancelled,
+			wantStatus:  domain.StatusCancelled,
+			wantVersion: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := domain.Upcast(tt.in)
+			if err != nil {
+				t.Fatalf("Upcast: %v", err)
+			}
+			if got.SchemaVersion != domain.EventSchemaVersion || got.Type != tt.wantType || got.AggregateID != "res-1" || got.Version != tt.wantVersion {
+				t.Fatalf("envelope = %+v; want %s of res-1 at version %d, schema version %d", got, tt.wantType, tt.wantVersion, domain.EventSchemaVersion)
+			}
+			if got.ID != tt.in.ID || !got.OccurredAt.Equal(tt.in.OccurredAt) {
+				t.Errorf("id, occurred at = %q, %s; want %q, %s kept", got.ID, got.OccurredAt, tt.in.ID, tt.in.OccurredAt)
+			}
+
+			event, err := got.DecodeEvent()
+			if err != nil {
+				t.Fatalf("DecodeEvent: %v", err)
+			}
+			snapshot := snapshotOf(t, event)
+			if snapshot.Status != tt.wantStatus || !snapshot.StartTime.Equal(start) || !snapshot.EndTime.Equal(tt.wantEnd) {
+				t.Errorf("snapshot = %+v; want %s starting %s, ending %s", snapshot, tt.wantStatus, start, tt.wantEnd)
+			}
+			if modified, ok := event.(*domain.ReservationModified); ok && !modified.PreviousStartTime.Equal(tt.wantPrev) {
+				t.Errorf("previous start = %s; want %s", modified.PreviousStartTime, tt.wantPrev)
+			}
+		})
+	}
+}
+
+func TestUpcastRejectsBadInput(t *testing.T) {
+	newer := domain.Envelope{Type: domain.EventReservationCreated, SchemaVersion: domain.EventSchemaVersion + 1, Data: []byte(`{}`)}
+	if _, err := domain.Upcast(newer); !errors.Is(err, domain.ErrUnsupportedSchemaVersion) {
+		t.Errorf("Upcast of a newer schema version = %v; want %v", err, domain.ErrUnsupportedSchemaVersion)
+	}
+
+	badTime := domain.Envelope{Data: []byte(`{"event_type":"ReservationCreated","reservation_id":"res-1","start_time":"tomorrow"}`)}
+	if _, err := domain.Upcast(badTime); err == nil {
+		t.Error("Upcast of a legacy body with an unparsable start_time succeeded")
+	}
+
+	if _, err := domain.Upcast(domain.Envelope{Data: []byte(`not json`)}); err == nil {
+		t.Error("Upcast of a non-JSON legacy body succeeded")
+	}
+}
+
+func TestUpcastLeavesCurrentVersionAlone(t *testing.T) {
+	in := domain.Envelope{
+		ID:            "evt-1",
+		Type:          domain.EventReservationCreated,
+		SchemaVersion: domain.EventSchemaVersion,
+		AggregateID:   "res-1",
+		Version:       1,
+		Data:          []byte(`{"reservation_id":"res-1"}`),
+	}
+	got, err := domain.Upcast(in)
+	if err != nil {
+		t.Fatalf("Upcast: %v", err)
+	}
+	if got.Type != in.Type || got.Version != in.Version || string(got.Data) != string(in.Data) {
+		t.Errorf("Upcast changed a current envelope: %+v", got)
+	}
+}
+
+func TestUpcastVersion1(t *testing.T) {
+	in := domain.Envelope{
+		ID:            "evt-1",
+		Type:          domain.EventReservationCancelled,
+		SchemaVersion: 1,
+		AggregateID:   "res-1",
+		Version:       2,
+		Data: []byte(`{"reservation_id":"res-1","event_id":"event-1","user_id":"user-1",` +
+			`"start_time":"2030-01-10T18:00:00Z","end_time":"2030-01-10T20:00:00Z","ticket_count":2,"status":"CANCELLED"}`),
+	}
+	got, err := domain.Upcast(in)
+	if err != nil {
+		t.Fatalf("Upcast: %v", err)
+	}
+	if got.SchemaVersion != domain.EventSchemaVersion || got.Type != in.Type || got.Version != in.Version || string(got.Data) != string(in.Data) {
+		t.Fatalf("envelope = %+v; want %+v at schema version %d", got, in, domain.EventSchemaVersion)
+	}
+
+	event, err := got.DecodeEvent()
+	if err != nil {
+		t.Fatalf("DecodeEvent: %v", err)
+	}
+	if snapshot := snapshotOf(t, event); snapshot.Status != domain.StatusCancelled || snapshot.ExpiresAt != nil {
+		t.Errorf("snapshot = %+v; want CANCELLED without an expiry", snapshot)
+	}
+}
+
+func snapshotOf(t *testing.T, event domain.DomainEvent) domain.ReservationSnapshot {
+	t.Helper()
+	switch e := event.(type) {
+	case *domain.ReservationCreated:
+		return e.ReservationSnapshot
+	case *domain.ReservationCancelled:
+		return e.ReservationSnapshot
+	case *domain.ReservationModified:
+		return e.ReservationSnapshot
+	}
+	t.Fatalf("unexpected event %T", event)
+	return domain.ReservationSnapshot{}
+}
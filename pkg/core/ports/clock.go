@@ -0,0 +1,9 @@
+package ports
+
+import "time"
+
+// Clock supplies the current time to time-dependent rules such as
+// rejecting past bookings, so tests can pin or advance it.
+type Clock interface {
+	Now() time.Time
+}
@@ -0,0 +1,23 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+)
+
+type EventRepository interface {
+	Save(ctx context.Context, event *domain.Event) error
+	Update(ctx context.Context, event *domain.Event) error
+	Delete(ctx context.Context, id string) error
+	GetByID(ctx context.Context, id string) (*domain.Event, error)
+	List(ctx context.Context) ([]*domain.Event, error)
+}
+
+type EventService interface {
+	Create(ctx context.Context, name, venue, timezone string, capacity, slotCapacity int) (*domain.Event, error)
+	Get(ctx context.Context, id string) (*domain.Event, error)
+	List(ctx context.Context) ([]*domain.Event, error)
+	Update(ctx context.Context, id, name, venue, timezone string, capacity, slotCapacity int) (*domain.Event, error)
+	Delete(ctx context.Context, id string) error
+}
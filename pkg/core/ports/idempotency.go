@@ -0,0 +1,28 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRecord remembers the outcome of a request made with an
+// Idempotency-Key so retries can be answered without redoing the work.
+type IdempotencyRecord struct {
+	Key          string
+	RequestHash  string
+	StatusCode   int // 0 while the original request is still in flight
+	ResponseBody []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+type IdempotencyStore interface {
+	// Reserve claims key for a new request and returns nil. If the key is
+	// already taken and not yet expired, the existing record is returned
+	// instead and nothing is claimed.
+	Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*IdempotencyRecord, error)
+	// Complete stores the response of a reserved key.
+	Complete(ctx context.Context, key string, statusCode int, body []byte) error
+	// Release forgets a reserved key so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
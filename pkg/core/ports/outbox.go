@@ -0,0 +1,45 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrUnpublishable marks publish failures that retrying cannot fix, such
+// as events that do not match their schema. Publishers wrap it.
+var ErrUnpublishable = errors.New("event cannot be published")
+
+// OutboxMaxAttempts is how many failed publishes a message gets before it
+// is parked. Migration 008 uses it as the max_attempts column default.
+const OutboxMaxAttempts = 25
+
+// OutboxMessage is an event recorded in the same transaction as the state
+// change that produced it, waiting to be relayed to the message broker.
+type OutboxMessage struct {
+	ID          int64
+	AggregateID string
+	EventType   string
+	Payload     []byte
+	CreatedAt   time.Time
+	Attempts    int // Failed publishes so far
+}
+
+type OutboxRepository interface {
+	// ClaimPending leases up to limit undispatched messages for the given
+	// duration so concurrent relays don't pick up the same rows. Messages
+	// whose lease expires without being marked are handed out again.
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]OutboxMessage, error)
+	MarkDispatched(ctx context.Context, id int64) error
+	// MarkFailed records a failed publish and releases the lease. The
+	// message is parked once it has failed OutboxMaxAttempts times.
+	MarkFailed(ctx context.Context, id int64, reason string) error
+	// Park sets a message aside for good, keeping reason for an operator.
+	// Parked messages are never claimed again.
+	Park(ctx context.Context, id int64, reason string) error
+	// Release hands a claimed message back without counting an attempt.
+	Release(ctx context.Context, id int64) error
+	// CountPending reports how many messages are still undispatched,
+	// leaving out parked ones.
+	CountPending(ctx context.Context) (int64, error)
+}
@@ -2,23 +2,82 @@ package ports
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
 )
 
 type ReservationRepository interface {
-	Save(ctx context.Context, reservation *domain.Reservation) error
+	// Save and Update write the given outbox messages in the same transaction
+	// as the reservation, so an event is recorded if and only if the change is.
+	// Both enforce the event's capacity; holds that lapsed by
+	// reservation.UpdatedAt, the time of the change, do not count.
+	Save(ctx context.Context, reservation *domain.Reservation, events ...OutboxMessage) error
+	// Update persists changes to an existing reservation. It only succeeds when
+	// the stored version matches reservation.Version and bumps it on success.
+	Update(ctx context.Context, reservation *domain.Reservation, events ...OutboxMessage) error
 	GetByID(ctx context.Context, id string) (*domain.Reservation, error)
 	GetByEventAndRange(ctx context.Context, eventID string, start, end time.Time) ([]*domain.Reservation, error)
+	// ListExpiredHolds returns up to limit HELD reservations whose hold
+	// lapsed at or before now, earliest expiry first.
+	ListExpiredHolds(ctx context.Context, now time.Time, limit int) ([]*domain.Reservation, error)
+	// ListTransitions returns the status changes recorded for a reservation,
+	// oldest first. Save and Update store a reservation's pending
+	// transitions in the same transaction as the change itself.
+	ListTransitions(ctx context.Context, reservationID string) ([]domain.Transition, error)
+}
+
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// ReservationPage is one page of a list query. NextPageToken is empty on
+// the last page.
+type ReservationPage struct {
+	Items         []*domain.Reservation
+	NextPageToken string
+}
+
+// ReservationReadModel serves list queries from the denormalised projection
+// maintained by the worker. It is eventually consistent with the repository.
+type ReservationReadModel interface {
+	ListByEvent(ctx context.Context, eventID string, start, end time.Time, limit int, pageToken string) (*ReservationPage, error)
+}
+
+// ReservationReadModelWriter maintains the projection. Listing items are
+// keyed by event and start time, so the last version applied is tracked
+// per reservation: writes at the same or an older version are skipped,
+// wherever the reservation's item now lives, and a write removes the item
+// at the slot the reservation was last written to.
+type ReservationReadModelWriter interface {
+	SaveReadModel(ctx context.Context, reservation *domain.Reservation) error
+	// MoveReadModel is SaveReadModel for a rescheduled reservation that
+	// also removes the item at oldStartTime.
+	MoveReadModel(ctx context.Context, reservation *domain.Reservation, oldStartTime time.Time) error
 }
 
 type EventPublisher interface {
-	Publish(ctx context.Context, event interface{}) error
+	Publish(ctx context.Context, event domain.Envelope) error
 }
 
 type ReservationService interface {
 	Create(ctx context.Context, userID, eventID string, start, end time.Time, ticketCount int) (*domain.Reservation, error)
+	// Hold reserves seats like Create, but the reservation lapses unless it
+	// is confirmed before its expiry.
+	Hold(ctx context.Context, userID, eventID string, start, end time.Time, ticketCount int) (*domain.Reservation, error)
+	Confirm(ctx context.Context, id string) (*domain.Reservation, error)
+	// Get and the methods acting on a single reservation return
+	// domain.ErrNotFound for reservations of users other than the
+	// authenticated one, unless they are staff (see domain.WithSubject and
+	// domain.WithStaff).
 	Get(ctx context.Context, id string) (*domain.Reservation, error)
-	ListByEvent(ctx context.Context, eventID string, start, end time.Time) ([]*domain.Reservation, error)
+	Cancel(ctx context.Context, id, reason string) (*domain.Reservation, error)
+	CheckIn(ctx context.Context, id string) (*domain.Reservation, error)
+	Complete(ctx context.Context, id string) (*domain.Reservation, error)
+	MarkNoShow(ctx context.Context, id, reason string) (*domain.Reservation, error)
+	// Transitions returns the status history of a reservation, oldest first.
+	Transitions(ctx context.Context, id string) ([]domain.Transition, error)
+	// Modify applies changes to a reservation. A non-zero expectedVersion must
+	// match the stored version or domain.ErrVersionConflict is returned.
+	Modify(ctx context.Context, id string, expectedVersion int, changes domain.ReservationChanges) (*domain.Reservation, error)
+	ListByEvent(ctx context.Context, eventID string, start, end time.Time, limit int, pageToken string) (*ReservationPage, error)
 }
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+	"github.com/google/uuid"
+)
+
+type EventService struct {
+	repo  ports.EventRepository
+	clock ports.Clock
+}
+
+func NewEventService(repo ports.EventRepository, clock ports.Clock) *EventService {
+	return &EventService{repo: repo, clock: clock}
+}
+
+func (s *EventService) Create(ctx context.Context, name, venue, timezone string, capacity, slotCapacity int) (*domain.Event, error) {
+	event, err := domain.NewEvent(name, venue, timezone, capacity, slotCapacity, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	event.ID = uuid.New().String()
+
+	if err := s.repo.Save(ctx, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (s *EventService) Get(ctx context.Context, id string) (*domain.Event, error) {
+	event, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, domain.ErrEventNotFound
+	}
+	return event, nil
+}
+
+func (s *EventService) List(ctx context.Context) ([]*domain.Event, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *EventService) Update(ctx context.Context, id, name, venue, timezone string, capacity, slotCapacity int) (*domain.Event, error) {
+	event, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := event.Update(name, venue, timezone, capacity, slotCapacity, s.clock.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (s *EventService) Delete(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// HoldSweeper periodically expires holds that were not confirmed in time,
+// publishing ReservationHoldExpired through the outbox. Several sweepers
+// may run at once; optimistic locking lets only one expire each hold.
+type HoldSweeper struct {
+	reservations *ReservationService
+
+	Interval  time.Duration
+	BatchSize int
+}
+
+func NewHoldSweeper(reservations *ReservationService) *HoldSweeper {
+	return &HoldSweeper{
+		reservations: reservations,
+		Interval:     15 * time.Second,
+		BatchSize:    100,
+	}
+}
+
+// Run sweeps until ctx is cancelled.
+func (s *HoldSweeper) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := s.reservations.ExpireHolds(ctx, s.BatchSize)
+			if err != nil {
+				log.Printf("Hold sweeper: %v", err)
+				break
+			}
+			if n > 0 {
+				log.Printf("Expired %d holds", n)
+			}
+			if n < s.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+)
+
+// OutboxRelay publishes events recorded in the outbox. Delivery is
+// at-least-once: a crash between Publish and MarkDispatched means the
+// message is sent again once its lease expires, so consumers must be
+// idempotent (the read model projection is).
+type OutboxRelay struct {
+	outbox    ports.OutboxRepository
+	publisher ports.EventPublisher
+
+	Interval  time.Duration
+	BatchSize int
+	Lease     time.Duration
+	// MaxBackoff caps how long Run waits between polls while publishing
+	// keeps failing. The wait doubles from Interval on each failure.
+	MaxBackoff time.Duration
+}
+
+func NewOutboxRelay(outbox ports.OutboxRepository, publisher ports.EventPublisher) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:     outbox,
+		publisher:  publisher,
+		Interval:   time.Second,
+		BatchSize:  100,
+		Lease:      30 * time.Second,
+		MaxBackoff: time.Minute,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	wait := r.Interval
+	for {
+		// Drain everything that is pending before waiting for the next poll
+		failed := false
+		for {
+			n, err := r.DispatchPending(ctx)
+			if err != nil {
+				log.Printf("Outbox relay: %v", err)
+				failed = true
+				break
+			}
+			if n < r.BatchSize {
+				break
+			}
+		}
+
+		if failed {
+			wait = min(wait*2, max(r.MaxBackoff, r.Interval))
+		} else {
+			wait = r.Interval
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// DispatchPending publishes one batch of pending messages and returns how
+// many were claimed. Messages that can never be published (undecodable, or
+// rejected with ports.ErrUnpublishable) are parked and skipped. Any other
+// failure stops the batch so the remaining messages keep their order; the
+// failing message is charged an attempt and the rest are released for the
+// next poll.
+func (r *OutboxRelay) DispatchPending(ctx context.Context) (int, error) {
+	msgs, err := r.outbox.ClaimPending(ctx, r.BatchSize, r.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, msg := range msgs {
+		envelope, err := decodeOutboxMessage(msg)
+		if err != nil {
+			err = fmt.Errorf("%w: %v", ports.ErrUnpublishable, err)
+		} else {
+			err = r.publisher.Publish(ctx, envelope)
+		}
+		if errors.Is(err, ports.ErrUnpublishable) {
+			log.Printf("Outbox relay: parking message %d: %v", msg.ID, err)
+			if err := r.outbox.Park(ctx, msg.ID, err.Error()); err != nil {
+				return len(msgs), err
+			}
+			continue
+		}
+		if err != nil {
+			if markErr := r.outbox.MarkFailed(ctx, msg.ID, err.Error()); markErr != nil {
+				log.Printf("Outbox relay: failed to record failure of message %d: %v", msg.ID, markErr)
+			}
+			for _, unsent := range msgs[i+1:] {
+				if relErr := r.outbox.Release(ctx, unsent.ID); relErr != nil {
+					log.Printf("Outbox relay: failed to release message %d: %v", unsent.ID, relErr)
+				}
+			}
+			return len(msgs), err
+		}
+
+		if err := r.outbox.MarkDispatched(ctx, msg.ID); err != nil {
+			// The message was sent; it will be re-sent after the lease expires
+			return len(msgs), err
+		}
+	}
+
+	return len(msgs), nil
+}
+
+// decodeOutboxMessage reads the envelope stored in the outbox and upcasts
+// it to the current schema. Rows written before envelopes were introduced
+// hold the bare event; they are wrapped using the row's own metadata.
+func decodeOutboxMessage(msg ports.OutboxMessage) (domain.Envelope, error) {
+	var envelope domain.Envelope
+	if err := json.Unmarshal(msg.Payload, &envelope); err != nil {
+		return domain.Envelope{}, err
+	}
+	if envelope.Type == "" {
+		envelope = domain.Envelope{
+			ID:          strconv.FormatInt(msg.ID, 10),
+			Type:        msg.EventType,
+			AggregateID: msg.AggregateID,
+			OccurredAt:  msg.CreatedAt.UTC(),
+			Data:        msg.Payload,
+		}
+	}
+	return domain.Upcast(envelope)
+}
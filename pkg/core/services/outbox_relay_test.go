@@ -0,0 +1,112 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/clock"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/memory"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/ports"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/services"
+)
+
+// recordingPublisher remembers what it published and fails for the
+// aggregates listed in fail.
+type recordingPublisher struct {
+	fail      map[string]error
+	published []string
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event domain.Envelope) error {
+	if err := p.fail[event.AggregateID]; err != nil {
+		return err
+	}
+	p.published = append(p.published, event.AggregateID)
+	return nil
+}
+
+// seedOutbox books three reservations on the seeded catalog, the middle
+// one with an outbox row that is not an event at all, and returns their ids.
+func seedOutbox(t *testing.T, store *memory.Store) []string {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now()
+	svc := services.NewReservationService(memory.NewReservationRepository(store), nil, clock.NewFake(now))
+	start := now.Add(24 * time.Hour)
+
+	first, err := svc.Create(ctx, "user-1", "event-1", start, start.Add(time.Hour), 1)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	poison, err := domain.NewReservation("user-1", "event-1", start, start.Add(time.Hour), 1, now)
+	if err != nil {
+		t.Fatalf("NewReservation: %v", err)
+	}
+	bad := ports.OutboxMessage{AggregateID: poison.ID, EventType: domain.EventReservationCreated, Payload: []byte(`"not an event"`), CreatedAt: now}
+	if err := memory.NewReservationRepository(store).Save(ctx, poison, bad); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	last, err := svc.Create(ctx, "user-1", "event-1", start, start.Add(time.Hour), 1)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return []string{first.ID, poison.ID, last.ID}
+}
+
+func TestOutboxRelayParksPoisonMessages(t *testing.T) {
+	store := memory.NewStore()
+	store.SeedCatalog()
+	ids := seedOutbox(t, store)
+	outbox := memory.NewOutboxRepository(store)
+
+	// The last one also breaks its schema
+	publisher := &recordingPublisher{fail: map[string]error{
+		ids[2]: fmt.Errorf("%w: missing field", ports.ErrUnpublishable),
+	}}
+	relay := services.NewOutboxRelay(outbox, publisher)
+
+	n, err := relay.DispatchPending(context.Background())
+	if err != nil || n != 3 {
+		t.Fatalf("DispatchPending = %d, %v; want 3 claimed", n, err)
+	}
+	if len(publisher.published) != 1 || publisher.published[0] != ids[0] {
+		t.Fatalf("published %v; want only %s", publisher.published, ids[0])
+	}
+	if pending, _ := outbox.CountPending(context.Background()); pending != 0 {
+		t.Fatalf("CountPending = %d; want both bad messages parked", pending)
+	}
+	if n, err := relay.DispatchPending(context.Background()); n != 0 || err != nil {
+		t.Fatalf("DispatchPending after parking = %d, %v; want nothing claimed", n, err)
+	}
+}
+
+func TestOutboxRelayStopsAtTransientFailure(t *testing.T) {
+	store := memory.NewStore()
+	store.SeedCatalog()
+	ids := seedOutbox(t, store)
+	outbox := memory.NewOutboxRepository(store)
+
+	down := errors.New("broker unreachable")
+	publisher := &recordingPublisher{fail: map[string]error{ids[0]: down}}
+	relay := services.NewOutboxRelay(outbox, publisher)
+
+	if _, err := relay.DispatchPending(context.Background()); !errors.Is(err, down) {
+		t.Fatalf("DispatchPending = %v; want %v", err, down)
+	}
+	if len(publisher.published) != 0 {
+		t.Fatalf("published %v after the head failed; want nothing out of order", publisher.published)
+	}
+
+	// Only the failed message is charged an attempt; the rest are claimable again
+	msgs, err := outbox.ClaimPending(context.Background(), 10, time.Minute)
+	if err != nil || len(msgs) != 3 {
+		t.Fatalf("ClaimPending = %d messages, %v; want 3", len(msgs), err)
+	}
+	if msgs[0].Attempts != 1 || msgs[1].Attempts != 0 || msgs[2].Attempts != 0 {
+		t.Fatalf("attempts = %d, %d, %d; want 1, 0, 0", msgs[0].Attempts, msgs[1].Attempts, msgs[2].Attempts)
+	}
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
@@ -9,67 +11,281 @@ import (
 	"github.com/google/uuid"
 )
 
+// DefaultHoldTTL is how long a hold keeps its seats unless configured.
+const DefaultHoldTTL = 10 * time.Minute
+
 type ReservationService struct {
 	repo      ports.ReservationRepository
-	publisher ports.EventPublisher
+	readModel ports.ReservationReadModel
+	clock     ports.Clock
+
+	// HoldTTL is how long Hold keeps seats before they are released.
+	HoldTTL time.Duration
 }
 
-func NewReservationService(repo ports.ReservationRepository, publisher ports.EventPublisher) *ReservationService {
+// NewReservationService wires the service. readModel is optional; when set,
+// list queries are served from it instead of the write database.
+func NewReservationService(repo ports.ReservationRepository, readModel ports.ReservationReadModel, clock ports.Clock) *ReservationService {
 	return &ReservationService{
 		repo:      repo,
-		publisher: publisher,
+		readModel: readModel,
+		clock:     clock,
+		HoldTTL:   DefaultHoldTTL,
 	}
 }
 
 func (s *ReservationService) Create(ctx context.Context, userID, eventID string, start, end time.Time, ticketCount int) (*domain.Reservation, error) {
 	// 1. Create Domain Entity (Validation happens here)
-	res, err := domain.NewReservation(userID, eventID, start, end, ticketCount)
+	now := s.clock.Now()
+	res, err := domain.NewReservation(userID, eventID, start, end, ticketCount, now)
 	if err != nil {
 		return nil, err
 	}
 	res.ID = uuid.New().String()
 
-	// 2. Check Availability (Simplified)
-	// Assuming DB constraints handle concurrency/overlap.
-
-	// 3. Persist to DB
-	if err := s.repo.Save(ctx, res); err != nil {
-		return nil, err
-	}
-
-	// 4. Publish Event
-	if s.publisher != nil {
-		event := struct {
-			EventID       string    `json:"event_id"`
-			EventType     string    `json:"event_type"`
-			ReservationID string    `json:"reservation_id"`
-			UserID        string    `json:"user_id"`
-			TicketCount   int       `json:"ticket_count"`
-			Status        string    `json:"status"`
-			Timestamp     time.Time `json:"timestamp"`
-		}{
-			EventID:       res.EventID, // The actual event (e.g., concert id)
-			EventType:     "ReservationCreated",
-			ReservationID: res.ID,
-			UserID:        res.UserID,
-			TicketCount:   res.TicketCount,
-			Status:        string(res.Status),
-			Timestamp:     time.Now(),
+	// 2. Build the event for the outbox
+	event, err := newOutboxMessage(ctx, domain.ReservationCreated{ReservationSnapshot: domain.NewReservationSnapshot(res)}, res.Version, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Persist to DB together with the event
+	// The repository checks event capacity atomically with the insert and
+	// returns domain.ErrCapacityExceeded when the event is sold out.
+	// Publishing happens asynchronously via the OutboxRelay.
+	if err := s.repo.Save(ctx, res, event); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *ReservationService) Hold(ctx context.Context, userID, eventID string, start, end time.Time, ticketCount int) (*domain.Reservation, error) {
+	now := s.clock.Now()
+	res, err := domain.NewHold(userID, eventID, start, end, ticketCount, s.HoldTTL, now)
+	if err != nil {
+		return nil, err
+	}
+	res.ID = uuid.New().String()
+
+	// Consumers see the hold as a creation with status HELD
+	event, err := newOutboxMessage(ctx, domain.ReservationCreated{ReservationSnapshot: domain.NewReservationSnapshot(res)}, res.Version, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Holds count against capacity like bookings do
+	if err := s.repo.Save(ctx, res, event); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *ReservationService) Confirm(ctx context.Context, id string) (*domain.Reservation, error) {
+	// Loses to a concurrent expiry with ErrVersionConflict
+	return s.transition(ctx, id, func(res *domain.Reservation, actor string, now time.Time) (domain.DomainEvent, error) {
+		if err := res.Confirm(actor, now); err != nil {
+			return nil, err
+		}
+		return domain.ReservationConfirmed{ReservationSnapshot: domain.NewReservationSnapshot(res)}, nil
+	})
+}
+
+func (s *ReservationService) CheckIn(ctx context.Context, id string) (*domain.Reservation, error) {
+	return s.transition(ctx, id, func(res *domain.Reservation, actor string, now time.Time) (domain.DomainEvent, error) {
+		if err := res.CheckIn(actor, now); err != nil {
+			return nil, err
+		}
+		return domain.ReservationCheckedIn{ReservationSnapshot: domain.NewReservationSnapshot(res)}, nil
+	})
+}
+
+func (s *ReservationService) Complete(ctx context.Context, id string) (*domain.Reservation, error) {
+	return s.transition(ctx, id, func(res *domain.Reservation, actor string, now time.Time) (domain.DomainEvent, error) {
+		if err := res.Complete(actor, now); err != nil {
+			return nil, err
 		}
+		return domain.ReservationCompleted{ReservationSnapshot: domain.NewReservationSnapshot(res)}, nil
+	})
+}
 
-		if err := s.publisher.Publish(ctx, event); err != nil {
-			// In production: return success but log error
+func (s *ReservationService) MarkNoShow(ctx context.Context, id, reason string) (*domain.Reservation, error) {
+	return s.transition(ctx, id, func(res *domain.Reservation, actor string, now time.Time) (domain.DomainEvent, error) {
+		if err := res.MarkNoShow(actor, reason, now); err != nil {
 			return nil, err
 		}
+		return domain.ReservationNoShow{ReservationSnapshot: domain.NewReservationSnapshot(res)}, nil
+	})
+}
+
+// transition loads a reservation, lets apply move it to its next status
+// on behalf of the actor in ctx and saves it together with the event
+// apply returns. A concurrent change fails it with ErrVersionConflict.
+func (s *ReservationService) transition(ctx context.Context, id string, apply func(res *domain.Reservation, actor string, now time.Time) (domain.DomainEvent, error)) (*domain.Reservation, error) {
+	res, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
+	now := s.clock.Now()
+	change, err := apply(res, domain.ActorFromContext(ctx), now)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := newOutboxMessage(ctx, change, res.Version+1, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, res, event); err != nil {
+		return nil, err
+	}
 	return res, nil
 }
 
+// Transitions returns the status history of a reservation, oldest first.
+func (s *ReservationService) Transitions(ctx context.Context, id string) ([]domain.Transition, error) {
+	if _, err := s.load(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListTransitions(ctx, id)
+}
+
+// ExpireHolds releases up to limit lapsed holds, returning how many were
+// expired. Holds confirmed or cancelled concurrently are skipped.
+func (s *ReservationService) ExpireHolds(ctx context.Context, limit int) (int, error) {
+	now := s.clock.Now()
+	holds, err := s.repo.ListExpiredHolds(ctx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, res := range holds {
+		if err := res.Expire(now); err != nil {
+			continue
+		}
+		event, err := newOutboxMessage(ctx, domain.ReservationHoldExpired{ReservationSnapshot: domain.NewReservationSnapshot(res)}, res.Version+1, now)
+		if err != nil {
+			return expired, err
+		}
+		if err := s.repo.Update(ctx, res, event); err != nil {
+			if errors.Is(err, domain.ErrVersionConflict) {
+				continue
+			}
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
 func (s *ReservationService) Get(ctx context.Context, id string) (*domain.Reservation, error) {
-	return s.repo.GetByID(ctx, id)
+	return s.load(ctx, id)
 }
 
-func (s *ReservationService) ListByEvent(ctx context.Context, eventID string, start, end time.Time) ([]*domain.Reservation, error) {
-	return s.repo.GetByEventAndRange(ctx, eventID, start, end)
+// load fetches a reservation the caller may access. Other users'
+// reservations are reported as not found, so ids cannot be probed.
+func (s *ReservationService) load(ctx context.Context, id string) (*domain.Reservation, error) {
+	res, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil || !res.AccessibleTo(ctx) {
+		return nil, domain.ErrNotFound
+	}
+	return res, nil
+}
+
+// Cancel releases a hold or booking, recording reason with the change.
+func (s *ReservationService) Cancel(ctx context.Context, id, reason string) (*domain.Reservation, error) {
+	return s.transition(ctx, id, func(res *domain.Reservation, actor string, now time.Time) (domain.DomainEvent, error) {
+		if err := res.Cancel(actor, reason, now); err != nil {
+			return nil, err
+		}
+		return domain.ReservationCancelled{ReservationSnapshot: domain.NewReservationSnapshot(res)}, nil
+	})
+}
+
+func (s *ReservationService) Modify(ctx context.Context, id string, expectedVersion int, changes domain.ReservationChanges) (*domain.Reservation, error) {
+	res, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if expectedVersion != 0 && expectedVersion != res.Version {
+		return nil, domain.ErrVersionConflict
+	}
+
+	previousStart := res.StartTime
+	now := s.clock.Now()
+	if err := res.Modify(changes, now); err != nil {
+		return nil, err
+	}
+
+	event, err := newOutboxMessage(ctx, domain.ReservationModified{
+		ReservationSnapshot: domain.NewReservationSnapshot(res),
+		PreviousStartTime:   previousStart.UTC(),
+	}, res.Version+1, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// The repository re-checks capacity for the new slot and the version
+	if err := s.repo.Update(ctx, res, event); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ListByEvent lists the reservations of an event. Users only see their
+// own; staff see everyone's. A filtered page can be shorter than limit,
+// so clients follow the page token rather than the page size.
+func (s *ReservationService) ListByEvent(ctx context.Context, eventID string, start, end time.Time, limit int, pageToken string) (*ports.ReservationPage, error) {
+	var page *ports.ReservationPage
+	if s.readModel != nil {
+		var err error
+		if page, err = s.readModel.ListByEvent(ctx, eventID, start, end, limit, pageToken); err != nil {
+			return nil, err
+		}
+	} else {
+		// Postgres returns the whole window in one page
+		items, err := s.repo.GetByEventAndRange(ctx, eventID, start, end)
+		if err != nil {
+			return nil, err
+		}
+		page = &ports.ReservationPage{Items: items}
+	}
+
+	visible := page.Items[:0]
+	for _, res := range page.Items {
+		if res.AccessibleTo(ctx) {
+			visible = append(visible, res)
+		}
+	}
+	page.Items = visible
+	return page, nil
+}
+
+// newOutboxMessage wraps a domain event for the outbox. version is the
+// reservation version once the change is persisted; updates bump it on
+// commit, so callers pass res.Version+1 for them. occurredAt is the time of
+// the change.
+func newOutboxMessage(ctx context.Context, event domain.DomainEvent, version int, occurredAt time.Time) (ports.OutboxMessage, error) {
+	envelope, err := domain.NewEnvelope(ctx, uuid.New().String(), event, version, occurredAt)
+	if err != nil {
+		return ports.OutboxMessage{}, err
+	}
+
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return ports.OutboxMessage{}, err
+	}
+
+	return ports.OutboxMessage{
+		AggregateID: envelope.AggregateID,
+		EventType:   envelope.Type,
+		Payload:     payload,
+		CreatedAt:   envelope.OccurredAt,
+	}, nil
 }
@@ -0,0 +1,257 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/clock"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/adapters/memory"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/domain"
+	"github.com/femisowemimo/booking-appointment/backend/pkg/core/services"
+)
+
+func newReservationService(t *testing.T, now time.Time) (*services.ReservationService, *clock.Fake) {
+	t.Helper()
+	store := memory.NewStore()
+	store.SeedCatalog()
+	fake := clock.NewFake(now)
+	return services.NewReservationService(memory.NewReservationRepository(store), nil, fake), fake
+}
+
+func TestCreateUsesClock(t *testing.T) {
+	now := time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+	svc, fake := newReservationService(t, now)
+	ctx := context.Background()
+
+	start := now.Add(time.Hour)
+	res, err := svc.Create(ctx, "user-1", "event-1", start, start.Add(time.Hour), 2)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !res.CreatedAt.Equal(now) || !res.UpdatedAt.Equal(now) {
+		t.Fatalf("timestamps = %s, %s; want %s", res.CreatedAt, res.UpdatedAt, now)
+	}
+
+	// The same slot is in the past once the clock passes it
+	fake.Advance(2 * time.Hour)
+	if _, err := svc.Create(ctx, "user-1", "event-1", start, start.Add(time.Hour), 2); !errors.Is(err, domain.ErrPastTime) {
+		t.Fatalf("Create after the start = %v; want %v", err, domain.ErrPastTime)
+	}
+}
+
+func TestCancelAndModifyStampClock(t *testing.T) {
+	now := time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+	svc, fake := newReservationService(t, now)
+	ctx := context.Background()
+
+	start := now.Add(24 * time.Hour)
+	res, err := svc.Create(ctx, "user-1", "event-1", start, start.Add(time.Hour), 1)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	fake.Advance(time.Minute)
+	tickets := 3
+	res, err = svc.Modify(ctx, res.ID, 0, domain.ReservationChanges{TicketCount: &tickets})
+	if err != nil {
+		t.Fatalf("Modify: %v", err)
+	}
+	if want := now.Add(time.Minute); !res.UpdatedAt.Equal(want) {
+		t.Fatalf("UpdatedAt after Modify = %s; want %s", res.UpdatedAt, want)
+	}
+
+	fake.Advance(time.Minute)
+	res, err = svc.Cancel(ctx, res.ID, "")
+	if err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if want := now.Add(2 * time.Minute); !res.UpdatedAt.Equal(want) {
+		t.Fatalf("UpdatedAt after Cancel = %s; want %s", res.UpdatedAt, want)
+	}
+}
+
+func TestHoldLifecycle(t *testing.T) {
+	now := time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+	svc, fake := newReservationService(t, now)
+	svc.HoldTTL = 5 * time.Minute
+	ctx := context.Background()
+	start := now.Add(24 * time.Hour)
+
+	confirmed, err := svc.Hold(ctx, "user-1", "event-1", start, start.Add(time.Hour), 2)
+	if err != nil {
+		t.Fatalf("Hold: %v", err)
+	}
+	if confirmed.Status != domain.StatusHeld || !confirmed.ExpiresAt.Equal(now.Add(5*time.Minute)) {
+		t.Fatalf("hold = %s expiring %v; want HELD expiring %s", confirmed.Status, confirmed.ExpiresAt, now.Add(5*time.Minute))
+	}
+	lapsed, err := svc.Hold(ctx, "user-2", "event-1", start, start.Add(time.Hour), 1)
+	if err != nil {
+		t.Fatalf("Hold: %v", err)
+	}
+
+	fake.Advance(4 * time.Minute)
+	if confirmed, err = svc.Confirm(ctx, confirmed.ID); err != nil {
+		t.Fatalf("Confirm within the TTL: %v", err)
+	}
+	if confirmed.Status != domain.StatusBooked || confirmed.ExpiresAt != nil {
+		t.Fatalf("confirmed = %s expiring %v; want BOOKED without expiry", confirmed.Status, confirmed.ExpiresAt)
+	}
+	var invalid *domain.ErrInvalidTransition
+	if _, err := svc.Confirm(ctx, confirmed.ID); !errors.As(err, &invalid) {
+		t.Fatalf("second Confirm = %v; want an invalid transition", err)
+	}
+
+	// Nothing has lapsed yet
+	if n, err := svc.ExpireHolds(ctx, 10); err != nil || n != 0 {
+		t.Fatalf("ExpireHolds before the TTL = %d, %v; want 0", n, err)
+	}
+
+	fake.Advance(time.Minute)
+	if _, err := svc.Confirm(ctx, lapsed.ID); !errors.Is(err, domain.ErrHoldExpired) {
+		t.Fatalf("Confirm after the TTL = %v; want %v", err, domain.ErrHoldExpired)
+	}
+	if n, err := svc.ExpireHolds(ctx, 10); err != nil || n != 1 {
+		t.Fatalf("ExpireHolds after the TTL = %d, %v; want 1", n, err)
+	}
+	got, err := svc.Get(ctx, lapsed.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status != domain.StatusExpired {
+		t.Fatalf("lapsed hold status = %s; want %s", got.Status, domain.StatusExpired)
+	}
+}
+
+func TestReservationStateMachine(t *testing.T) {
+	now := time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+	svc, fake := newReservationService(t, now)
+	ctx := domain.WithActor(context.Background(), "staff-1")
+	start := now.Add(time.Hour)
+
+	res, err := svc.Create(ctx, "user-1", "event-1", start, start.Add(time.Hour), 2)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	var invalid *domain.ErrInvalidTransition
+	if _, err := svc.Complete(ctx, res.ID); !errors.As(err, &invalid) || invalid.From != domain.StatusBooked || invalid.To != domain.StatusCompleted {
+		t.Fatalf("Complete before check-in = %v; want BOOKED to COMPLETED refused", err)
+	}
+	if _, err := svc.MarkNoShow(ctx, res.ID, "late"); !errors.Is(err, domain.ErrNotStarted) {
+		t.Fatalf("MarkNoShow before the start = %v; want %v", err, domain.ErrNotStarted)
+	}
+
+	fake.Advance(time.Hour)
+	if res, err = svc.CheckIn(ctx, res.ID); err != nil {
+		t.Fatalf("CheckIn: %v", err)
+	}
+	if _, err := svc.Cancel(ctx, res.ID, "changed plans"); !errors.As(err, &invalid) {
+		t.Fatalf("Cancel after check-in = %v; want an invalid transition", err)
+	}
+	if _, err := svc.Modify(ctx, res.ID, 0, domain.ReservationChanges{}); !errors.Is(err, domain.ErrNotModifiable) {
+		t.Fatalf("Modify after check-in = %v; want %v", err, domain.ErrNotModifiable)
+	}
+
+	fake.Advance(time.Hour)
+	if res, err = svc.Complete(ctx, res.ID); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if res.Status != domain.StatusCompleted {
+		t.Fatalf("status = %s; want %s", res.Status, domain.StatusCompleted)
+	}
+
+	transitions, err := svc.Transitions(ctx, res.ID)
+	if err != nil {
+		t.Fatalf("Transitions: %v", err)
+	}
+	want := []domain.ReservationStatus{domain.StatusBooked, domain.StatusCheckedIn, domain.StatusCompleted}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %+v; want %v", transitions, want)
+	}
+	for i, tr := range transitions {
+		if tr.To != want[i] {
+			t.Fatalf("transition %d to %s; want %s", i, tr.To, want[i])
+		}
+	}
+	if last := transitions[2]; last.Actor != "staff-1" || !last.At.Equal(now.Add(2*time.Hour)) {
+		t.Fatalf("completion by %q at %s; want staff-1 at %s", last.Actor, last.At, now.Add(2*time.Hour))
+	}
+}
+
+func TestExpireHoldsRecordsSystemActor(t *testing.T) {
+	now := time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+	svc, fake := newReservationService(t, now)
+	ctx := context.Background()
+	start := now.Add(24 * time.Hour)
+
+	hold, err := svc.Hold(ctx, "user-1", "event-1", start, start.Add(time.Hour), 1)
+	if err != nil {
+		t.Fatalf("Hold: %v", err)
+	}
+	fake.Advance(svc.HoldTTL)
+	if n, err := svc.ExpireHolds(ctx, 10); err != nil || n != 1 {
+		t.Fatalf("ExpireHolds = %d, %v; want 1", n, err)
+	}
+
+	transitions, err := svc.Transitions(ctx, hold.ID)
+	if err != nil {
+		t.Fatalf("Transitions: %v", err)
+	}
+	if len(transitions) != 2 {
+		t.Fatalf("transitions = %+v; want hold and expiry", transitions)
+	}
+	if last := transitions[1]; last.From != domain.StatusHeld || last.To != domain.StatusExpired || last.Actor != domain.ActorSystem {
+		t.Fatalf("expiry = %+v; want HELD to EXPIRED by %s", last, domain.ActorSystem)
+	}
+}
+
+func TestOtherUsersReservationsAreNotFound(t *testing.T) {
+	now := time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+	svc, _ := newReservationService(t, now)
+	owner := domain.WithSubject(context.Background(), "user-1")
+	other := domain.WithSubject(context.Background(), "user-2")
+	start := now.Add(24 * time.Hour)
+
+	res, err := svc.Create(owner, "user-1", "event-1", start, start.Add(time.Hour), 1)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	tickets := 2
+	for name, call := range map[string]func(ctx context.Context) error{
+		"Get": func(ctx context.Context) error {
+			_, err := svc.Get(ctx, res.ID)
+			return err
+		},
+		"Modify": func(ctx context.Context) error {
+			_, err := svc.Modify(ctx, res.ID, 0, domain.ReservationChanges{TicketCount: &tickets})
+			return err
+		},
+		"Transitions": func(ctx context.Context) error {
+			_, err := svc.Transitions(ctx, res.ID)
+			return err
+		},
+		"Cancel": func(ctx context.Context) error {
+			_, err := svc.Cancel(ctx, res.ID, "")
+			return err
+		},
+	} {
+		if err := call(other); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("%s by another user = %v; want %v", name, err, domain.ErrNotFound)
+		}
+	}
+
+	// Nothing the other user tried went through
+	got, err := svc.Get(owner, res.ID)
+	if err != nil {
+		t.Fatalf("Get by the owner: %v", err)
+	}
+	if got.Status != domain.StatusBooked || got.TicketCount != 1 || got.Version != 1 {
+		t.Fatalf("reservation = %s, %d tickets, version %d; want untouched", got.Status, got.TicketCount, got.Version)
+	}
+	if _, err := svc.Cancel(owner, res.ID, ""); err != nil {
+		t.Fatalf("Cancel by the owner: %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+// Package schemas embeds the JSON Schema documents for published events.
+// Event schemas are named <EventType>.v<schema_version>.json and describe
+// the whole envelope; the lowercase files are shared definitions they
+// reference.
+package schemas
+
+import "embed"
+
+//go:embed *.json
+var FS embed.FS